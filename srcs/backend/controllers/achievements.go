@@ -0,0 +1,173 @@
+package controllers
+
+import (
+	"api/database"
+	"api/models"
+	"api/utils"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	achievementWinStreak   = 10
+	achievementBoostedHits = 3
+	achievementVeteran     = 50
+)
+
+type AchievementUnlockedEvent struct {
+	Type        string             `json:"type"`
+	UserID      uint               `json:"userId"`
+	Achievement models.Achievement `json:"achievement"`
+	UnlockedAt  time.Time          `json:"unlockedAt"`
+}
+
+type TournamentResultInput struct {
+	TournamentID string `json:"tournament_id"`
+	WinnerID     uint   `json:"winner_id" binding:"required"`
+}
+
+func GetAchievements(ctx *gin.Context) {
+	userId, exists := ctx.Get("UserId")
+	id, ok := userId.(uint)
+	if exists == false || !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: You must be logged in to access this resource."})
+		return
+	}
+
+	var unlocked []models.UserAchievement
+	if err := database.DB.Where("user_id = ?", id).Find(&unlocked).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	unlockedAt := make(map[string]time.Time, len(unlocked))
+	for _, achievement := range unlocked {
+		unlockedAt[achievement.Code] = achievement.UnlockedAt
+	}
+
+	achievements := make([]models.AchievementResponse, 0, len(models.Achievements))
+	for _, achievement := range models.Achievements {
+		response := models.AchievementResponse{Achievement: achievement}
+		if at, ok := unlockedAt[achievement.Code]; ok {
+			response.UnlockedAt = &at
+		}
+		achievements = append(achievements, response)
+	}
+	ctx.JSON(http.StatusOK, achievements)
+}
+
+func findAchievement(code string) (models.Achievement, bool) {
+	for _, achievement := range models.Achievements {
+		if achievement.Code == code {
+			return achievement, true
+		}
+	}
+	return models.Achievement{}, false
+}
+
+// storeAchievements saves the codes the user does not have yet and returns
+// the achievements that were actually unlocked, already unlocked codes are
+// ignored.
+func storeAchievements(tx *gorm.DB, userId uint, codes []string, now time.Time) ([]models.Achievement, error) {
+	var unlocked []models.Achievement
+	for _, code := range codes {
+		achievement, ok := findAchievement(code)
+		if !ok {
+			continue
+		}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.UserAchievement{
+			UserID:     userId,
+			Code:       code,
+			UnlockedAt: now,
+		})
+		if result.Error != nil {
+			return unlocked, result.Error
+		}
+		if result.RowsAffected > 0 {
+			unlocked = append(unlocked, achievement)
+		}
+	}
+	return unlocked, nil
+}
+
+// unlockAchievements stores the codes and announces the new ones.
+func unlockAchievements(userId uint, codes []string) {
+	now := time.Now()
+	unlocked, err := storeAchievements(database.DB, userId, codes, now)
+	if err != nil {
+		log.Printf("error unlocking achievements for user %d: %v", userId, err)
+	}
+
+	for _, achievement := range unlocked {
+		event := AchievementUnlockedEvent{
+			Type:        "ACHIEVEMENT_UNLOCKED",
+			UserID:      userId,
+			Achievement: achievement,
+			UnlockedAt:  now,
+		}
+		if err := utils.PushEvent([]uint{userId}, event); err != nil {
+			log.Printf("error pushing ACHIEVEMENT_UNLOCKED to user %d: %v", userId, err)
+		}
+	}
+}
+
+// EvaluateGameAchievements runs once a game is saved, for both players.
+func EvaluateGameAchievements(game *models.GameHistory) {
+	for _, playerId := range []uint64{game.Player1ID, game.Player2ID} {
+		var games []models.GameHistory
+		if err := database.DB.
+			Where("player1_id = ? OR player2_id = ?", playerId, playerId).
+			Order("created_at asc").
+			Find(&games).Error; err != nil {
+			log.Printf("error evaluating achievements of user %d: %v", playerId, err)
+			continue
+		}
+		stats := computeUserStats(playerId, games)
+
+		conceded, boosts := game.Score2, game.Player1Boosts
+		if playerId == game.Player2ID {
+			conceded, boosts = game.Score1, game.Player2Boosts
+		}
+		won := game.WinnerID == playerId
+
+		var codes []string
+		if stats.Wins >= 1 {
+			codes = append(codes, models.AchievementFirstWin)
+		}
+		if stats.BestStreak >= achievementWinStreak {
+			codes = append(codes, models.AchievementWinStreak)
+		}
+		if won && conceded == 0 && !game.Forfeit {
+			codes = append(codes, models.AchievementShutout)
+		}
+		if boosts >= achievementBoostedHits {
+			codes = append(codes, models.AchievementBoostMaster)
+		}
+		if stats.Games >= achievementVeteran {
+			codes = append(codes, models.AchievementVeteran)
+		}
+		unlockAchievements(uint(playerId), codes)
+	}
+}
+
+// RecordTournamentResult is called by the hub when a tournament final ends.
+func RecordTournamentResult(ctx *gin.Context) {
+	var input TournamentResultInput
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data"})
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, input.WinnerID).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	unlockAchievements(user.ID, []string{models.AchievementTournamentChampion})
+	ctx.JSON(http.StatusCreated, gin.H{"success": "Tournament result recorded"})
+}
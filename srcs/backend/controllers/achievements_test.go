@@ -0,0 +1,62 @@
+package controllers
+
+import (
+	"api/models"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+func achievementCodes(achievements []models.Achievement) []string {
+	var codes []string
+	for _, achievement := range achievements {
+		codes = append(codes, achievement.Code)
+	}
+	return codes
+}
+
+func TestStoreAchievementsIsIdempotent(t *testing.T) {
+	withTestDB(t, func(tx *gorm.DB) {
+		user := createTestUser(t, tx, "achiever")
+		first := time.Now().Add(-time.Hour).Truncate(time.Second)
+
+		unlocked, err := storeAchievements(tx, user.ID, []string{models.AchievementFirstWin, "unknown", models.AchievementShutout}, first)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if codes := achievementCodes(unlocked); len(codes) != 2 || codes[0] != models.AchievementFirstWin || codes[1] != models.AchievementShutout {
+			t.Fatalf("first evaluation unlocked %v", codes)
+		}
+
+		// The next game meets the same conditions plus a new one.
+		unlocked, err = storeAchievements(tx, user.ID, []string{models.AchievementFirstWin, models.AchievementShutout, models.AchievementVeteran}, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if codes := achievementCodes(unlocked); len(codes) != 1 || codes[0] != models.AchievementVeteran {
+			t.Fatalf("second evaluation unlocked %v, want only %s", codes, models.AchievementVeteran)
+		}
+
+		unlocked, err = storeAchievements(tx, user.ID, []string{models.AchievementFirstWin, models.AchievementVeteran}, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(unlocked) != 0 {
+			t.Fatalf("third evaluation unlocked %v", achievementCodes(unlocked))
+		}
+
+		var rows []models.UserAchievement
+		if err := tx.Where("user_id = ?", user.ID).Order("code").Find(&rows).Error; err != nil {
+			t.Fatal(err)
+		}
+		if len(rows) != 3 {
+			t.Fatalf("%d achievements stored, want 3", len(rows))
+		}
+		for _, row := range rows {
+			if row.Code == models.AchievementFirstWin && !row.UnlockedAt.Equal(first) {
+				t.Errorf("%s unlocked at %v, want the first unlock %v", row.Code, row.UnlockedAt, first)
+			}
+		}
+	})
+}
@@ -0,0 +1,199 @@
+package controllers
+
+import (
+	"api/database"
+	"api/middleware"
+	"api/models"
+	"api/utils"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func AdminUsers(ctx *gin.RouterGroup) {
+	ctx.POST("/:userId/suspend", SuspendUser)
+	ctx.DELETE("/:userId/suspend", UnsuspendUser)
+	ctx.PUT("/:userId/role", middleware.RequireRole(models.RoleAdmin), UpdateUserRole)
+}
+
+// canSanction keeps moderators from sanctioning other staff members or
+// lifting their sanctions, only admins may.
+func canSanction(ctx *gin.Context, target *models.User) bool {
+	return target.Role == models.RoleUser || ctx.GetString("Role") == models.RoleAdmin
+}
+
+// SuspendUser locks the account until the given time: every session is
+// revoked and the hub drops the user's connections.
+func SuspendUser(ctx *gin.Context) {
+	userId, exists := ctx.Get("UserId")
+	id, ok := userId.(uint)
+	if exists == false || !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: You must be logged in to access this resource."})
+		return
+	}
+
+	targetId, err := strconv.ParseUint(ctx.Param("userId"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format of user id"})
+		return
+	}
+
+	var input models.SuspendUserDto
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data"})
+		return
+	}
+	if !input.Until.After(time.Now()) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "The suspension must end in the future"})
+		return
+	}
+	if uint(targetId) == id {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "You cannot suspend yourself"})
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, targetId).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if !canSanction(ctx, &user) {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Only admins can suspend staff members"})
+		return
+	}
+
+	sanction := models.Sanction{
+		UserID:   user.ID,
+		Kind:     models.SanctionSuspend,
+		Reason:   input.Reason,
+		IssuedBy: id,
+		Until:    input.Until,
+	}
+	var suspendedUntil *time.Time
+	if err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&sanction).Error; err != nil {
+			return err
+		}
+		var err error
+		if suspendedUntil, err = syncSuspendedUntil(tx, user.ID); err != nil {
+			return err
+		}
+		if err := auditSanction(tx, &sanction, id, "applied"); err != nil {
+			return err
+		}
+		return RevokeUserSessions(tx, user.ID, "")
+	}); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	event := gin.H{"type": "ACCOUNT_SUSPENDED", "until": suspendedUntil}
+	if err := utils.DisconnectUsers([]uint{user.ID}, event); err != nil {
+		log.Printf("error disconnecting suspended user %d: %v", user.ID, err)
+	}
+	ctx.JSON(http.StatusOK, sanction)
+}
+
+func UnsuspendUser(ctx *gin.Context) {
+	userId, exists := ctx.Get("UserId")
+	id, ok := userId.(uint)
+	if exists == false || !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: You must be logged in to access this resource."})
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, ctx.Param("userId")).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if !canSanction(ctx, &user) {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Only admins can lift the suspension of staff members"})
+		return
+	}
+
+	var sanctions []models.Sanction
+	if err := database.DB.
+		Where("user_id = ? AND kind = ? AND lifted_at IS NULL AND until > ?", user.ID, models.SanctionSuspend, time.Now()).
+		Find(&sanctions).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(sanctions) == 0 {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "This user is not suspended"})
+		return
+	}
+
+	now := time.Now()
+	if err := database.DB.Transaction(func(tx *gorm.DB) error {
+		for i := range sanctions {
+			if err := tx.Model(&sanctions[i]).Update("lifted_at", now).Error; err != nil {
+				return err
+			}
+			if err := auditSanction(tx, &sanctions[i], id, "lifted"); err != nil {
+				return err
+			}
+		}
+		_, err := syncSuspendedUntil(tx, user.ID)
+		return err
+	}); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"success": "Suspension lifted"})
+}
+
+// UpdateUserRole is reserved to admins. The role travels in the access token,
+// so the user's sessions are ended for the new one to apply right away.
+func UpdateUserRole(ctx *gin.Context) {
+	userId, exists := ctx.Get("UserId")
+	id, ok := userId.(uint)
+	if exists == false || !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: You must be logged in to access this resource."})
+		return
+	}
+
+	targetId, err := strconv.ParseUint(ctx.Param("userId"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format of user id"})
+		return
+	}
+
+	var input struct {
+		Role string `json:"role" binding:"required,oneof=user moderator admin"`
+	}
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data"})
+		return
+	}
+	if uint(targetId) == id {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "You cannot change your own role"})
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, targetId).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Update("role", input.Role).Error; err != nil {
+			return err
+		}
+		return RevokeUserSessions(tx, user.ID, "")
+	}); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	event := gin.H{"type": "ROLE_UPDATED", "role": input.Role}
+	if err := utils.DisconnectUsers([]uint{user.ID}, event); err != nil {
+		log.Printf("error disconnecting user %d: %v", user.ID, err)
+	}
+	ctx.JSON(http.StatusOK, gin.H{"id": user.ID, "role": input.Role})
+}
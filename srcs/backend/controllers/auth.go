@@ -7,6 +7,7 @@ import (
 	"api/utils"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/pquerna/otp/totp"
@@ -19,6 +20,7 @@ func Auth(ctx *gin.RouterGroup) {
 	ctx.POST("/signin", SignIn)
 	ctx.POST("/signup", SignUp)
 	ctx.POST("/signout", SignOut)
+	ctx.POST("/refresh", RefreshTokens)
 	ctx.GET("/generate2FA", Generate2FAcode)
 	ctx.GET("/2FA-status", GetUser2FAStatus)
 	ctx.POST("/verify2FA", Verify2FAcode)
@@ -88,15 +90,20 @@ func Check2FAcode(ctx *gin.Context) {
 		return
 	}
 
-	token, err := utils.CreateToken(user.ID)
+	if user.IsSuspended() {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Your account is suspended until " + user.SuspendedUntil.Format(time.RFC3339) + "."})
+		return
+	}
+
+	tokens, err := IssueTokens(ctx, database.DB, user.ID, "")
 	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "An error occurred while generating the token. Please try again."})
 		prometheus.RecordLoginAttempt(false)
 		return
 	}
+	SetTokenCookies(ctx, tokens)
 	prometheus.IncrementActiveUsers()
 	prometheus.RecordLoginAttempt(true)
-	ctx.SetCookie("access_token", token, 0, "/", "", false, true)
 	ctx.JSON(http.StatusOK, gin.H{"message": "2FA code verified successfully"})
 }
 
@@ -200,7 +207,21 @@ func Generate2FAcode(ctx *gin.Context) {
 }
 
 func SignOut(ctx *gin.Context) {
-	ctx.SetCookie("access_token", "", 0, "/", "", false, true)
+	if sessionId := ctx.GetString("SessionId"); sessionId != "" {
+		RevokeSession(database.DB, sessionId)
+	} else if refreshToken, err := ctx.Cookie("refresh_token"); err == nil && refreshToken != "" {
+		var current models.RefreshToken
+		if err := database.DB.Where("token_hash = ?", utils.HashToken(refreshToken)).First(&current).Error; err == nil {
+			RevokeSession(database.DB, current.SessionID)
+		}
+	}
+	if jti, exists := ctx.Get("TokenId"); exists {
+		expiresAt, _ := ctx.Get("TokenExpiresAt")
+		exp, _ := expiresAt.(time.Time)
+		RevokeAccessToken(database.DB, jti.(string), exp)
+	}
+
+	ClearTokenCookies(ctx)
 	ctx.JSON(http.StatusCreated, gin.H{"succes": "Logout successfully"})
 	prometheus.DecrementActiveUsers()
 }
@@ -238,13 +259,12 @@ func SignUp(ctx *gin.Context) {
 		return
 	}
 
-	token, err := utils.CreateToken(newUser.ID)
+	tokens, err := IssueTokens(ctx, database.DB, newUser.ID, "")
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-
-	ctx.SetCookie("access_token", token, 0, "/", "", false, true)
+	SetTokenCookies(ctx, tokens)
 
 	ctx.JSON(http.StatusCreated, gin.H{"succes": "User created"})
 }
@@ -281,18 +301,24 @@ func SignIn(ctx *gin.Context) {
 		return
 	}
 
+	if user.IsSuspended() {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Your account is suspended until " + user.SuspendedUntil.Format(time.RFC3339) + "."})
+		prometheus.RecordLoginAttempt(false)
+		return
+	}
+
 	var twoFactor models.TwoFactorAuth
 	err = database.DB.Where("user_id = ?", user.ID).First(&twoFactor).Error
 	if err != nil && err == gorm.ErrRecordNotFound || !twoFactor.IsActive {
-		token, err := utils.CreateToken(user.ID)
+		tokens, err := IssueTokens(ctx, database.DB, user.ID, "")
 		if err != nil {
 			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "An error occurred while generating the token. Please try again."})
 			prometheus.RecordLoginAttempt(false)
 			return
 		}
+		SetTokenCookies(ctx, tokens)
 		prometheus.IncrementActiveUsers()
 		prometheus.RecordLoginAttempt(false)
-		ctx.SetCookie("access_token", token, 0, "/", "", false, true)
 		ctx.JSON(http.StatusOK, gin.H{"success": "User connected"})
 		return
 	}
@@ -0,0 +1,124 @@
+package controllers
+
+import (
+	"api/database"
+	"api/models"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func Blocks(ctx *gin.RouterGroup) {
+	ctx.GET("", GetBlockedUsers)
+	ctx.POST("/:userId", BlockUser)
+	ctx.DELETE("/:userId", UnblockUser)
+}
+
+// IsBlocked tells whether either user blocked the other one.
+func IsBlocked(userId uint, targetId uint) (bool, error) {
+	var count int64
+	err := database.DB.Model(&models.Block{}).
+		Where("(blocker_id = ? AND blocked_id = ?) OR (blocker_id = ? AND blocked_id = ?)", userId, targetId, targetId, userId).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func GetBlockedUsers(ctx *gin.Context) {
+	userId, exists := ctx.Get("UserId")
+	id, ok := userId.(uint)
+	if exists == false || !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: You must be logged in to access this resource."})
+		return
+	}
+
+	blocked := []models.UserResponse{}
+	err := database.DB.Raw(`
+		SELECT u.id, u.display_name, u.nickname, u.avatar
+		FROM blocks b JOIN "users" u ON b.blocked_id = u.id
+		WHERE b.blocker_id = ?
+		ORDER BY b.created_at DESC
+		`, id).Scan(&blocked).Error
+
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, blocked)
+}
+
+// BlockUser also ends any friendship or pending request between the two.
+func BlockUser(ctx *gin.Context) {
+	target := ctx.Param("userId")
+	targetId, err := strconv.ParseUint(target, 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format of user id"})
+		return
+	}
+
+	userId, exists := ctx.Get("UserId")
+	id, ok := userId.(uint)
+	if exists == false || !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: You must be logged in to access this resource."})
+		return
+	}
+
+	if uint(targetId) == id {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "You cannot block yourself."})
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, targetId).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	var existing models.Block
+	if err := database.DB.First(&existing, "blocker_id = ? AND blocked_id = ?", id, targetId).Error; err == nil {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "You already blocked this user."})
+		return
+	}
+
+	if err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("(user_id = ? AND friend_id = ?) OR (user_id = ? AND friend_id = ?)", id, targetId, targetId, id).
+			Delete(&models.FriendShip{}).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.Block{BlockerID: id, BlockedID: uint(targetId)}).Error
+	}); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"success": "User blocked"})
+}
+
+func UnblockUser(ctx *gin.Context) {
+	target := ctx.Param("userId")
+	targetId, err := strconv.ParseUint(target, 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format of user id"})
+		return
+	}
+
+	userId, exists := ctx.Get("UserId")
+	id, ok := userId.(uint)
+	if exists == false || !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: You must be logged in to access this resource."})
+		return
+	}
+
+	result := database.DB.Where("blocker_id = ? AND blocked_id = ?", id, targetId).Delete(&models.Block{})
+	if result.Error != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+	if result.RowsAffected == 0 {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "This user is not blocked."})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"success": "User unblocked"})
+}
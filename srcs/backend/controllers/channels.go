@@ -0,0 +1,755 @@
+package controllers
+
+import (
+	"api/database"
+	"api/models"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+func Channels(ctx *gin.RouterGroup) {
+	ctx.GET("", ListChannels)
+	ctx.POST("", CreateChannel)
+	ctx.GET("/:channelId", GetChannel)
+	ctx.PUT("/:channelId", UpdateChannel)
+	ctx.GET("/:channelId/messages", GetChannelMessages)
+	ctx.POST("/:channelId/join", JoinChannel)
+	ctx.POST("/:channelId/leave", LeaveChannel)
+	ctx.POST("/:channelId/members", AddChannelMember)
+	ctx.PUT("/:channelId/members/:userId", UpdateChannelMember)
+	ctx.DELETE("/:channelId/members/:userId", RemoveChannelMember)
+	ctx.GET("/:channelId/sanctions", GetChannelSanctions)
+	ctx.POST("/:channelId/bans/:userId", BanChannelMember)
+	ctx.DELETE("/:channelId/bans/:userId", UnbanChannelMember)
+	ctx.POST("/:channelId/mutes/:userId", MuteChannelMember)
+	ctx.DELETE("/:channelId/mutes/:userId", UnmuteChannelMember)
+}
+
+var channelRoleRank = map[string]int{
+	models.ChannelRoleMember: 0,
+	models.ChannelRoleAdmin:  1,
+	models.ChannelRoleOwner:  2,
+}
+
+type ChannelResponse struct {
+	models.Channel
+	Role    string `json:"role,omitempty"`
+	Members int64  `json:"members"`
+}
+
+func GetChannelMembership(channelId uint, userId uint) (*models.ChannelMember, error) {
+	var member models.ChannelMember
+	if err := database.DB.First(&member, "channel_id = ? AND user_id = ?", channelId, userId).Error; err != nil {
+		return nil, err
+	}
+	return &member, nil
+}
+
+// GetChannelMemberIds lists everyone in the channel, the hub fans messages out
+// to them.
+func GetChannelMemberIds(channelId uint) ([]uint, error) {
+	var ids []uint
+	err := database.DB.Model(&models.ChannelMember{}).Where("channel_id = ?", channelId).Pluck("user_id", &ids).Error
+	return ids, err
+}
+
+// GetChannelSanction returns the ban or mute running against the user in the
+// channel, gorm.ErrRecordNotFound when there is none.
+func GetChannelSanction(channelId uint, userId uint, kind string) (*models.ChannelSanction, error) {
+	var sanction models.ChannelSanction
+	if err := database.DB.
+		Where("channel_id = ? AND user_id = ? AND kind = ? AND (until IS NULL OR until > ?)", channelId, userId, kind, time.Now()).
+		First(&sanction).Error; err != nil {
+		return nil, err
+	}
+	return &sanction, nil
+}
+
+func parseChannelId(ctx *gin.Context) (uint, bool) {
+	channelId, err := strconv.ParseUint(ctx.Param("channelId"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format of channel id"})
+		return 0, false
+	}
+	return uint(channelId), true
+}
+
+func ListChannels(ctx *gin.Context) {
+	userId, exists := ctx.Get("UserId")
+	id, ok := userId.(uint)
+	if exists == false || !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: You must be logged in to access this resource."})
+		return
+	}
+
+	// Private channels are only listed to their members.
+	channels := []ChannelResponse{}
+	if err := database.DB.Raw(`
+		SELECT channels.*, COALESCE(member.role, '') AS role,
+			(SELECT COUNT(*) FROM channel_members WHERE channel_id = channels.id) AS members
+		FROM channels
+		LEFT JOIN channel_members member ON member.channel_id = channels.id AND member.user_id = ?
+		WHERE channels.visibility <> ? OR member.user_id IS NOT NULL
+		ORDER BY channels.name
+		`, id, models.ChannelPrivate).Scan(&channels).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, channels)
+}
+
+func CreateChannel(ctx *gin.Context) {
+	userId, exists := ctx.Get("UserId")
+	id, ok := userId.(uint)
+	if exists == false || !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: You must be logged in to access this resource."})
+		return
+	}
+
+	var input models.CreateChannelDto
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data"})
+		return
+	}
+	if (input.Visibility == models.ChannelProtected) != (input.Password != "") {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "A password is required for protected channels only"})
+		return
+	}
+
+	var existing models.Channel
+	if err := database.DB.Where("name = ?", input.Name).First(&existing).Error; err == nil {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "Channel name already taken"})
+		return
+	}
+
+	channel := models.Channel{
+		Name:       input.Name,
+		Visibility: input.Visibility,
+		OwnerID:    id,
+	}
+	if input.Password != "" {
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+			return
+		}
+		channel.Password = string(hashedPassword)
+	}
+
+	if err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&channel).Error; err != nil {
+			return err
+		}
+		return tx.Create(&models.ChannelMember{
+			ChannelID: channel.ID,
+			UserID:    id,
+			Role:      models.ChannelRoleOwner,
+			JoinedAt:  time.Now(),
+		}).Error
+	}); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, ChannelResponse{Channel: channel, Role: models.ChannelRoleOwner, Members: 1})
+}
+
+func GetChannel(ctx *gin.Context) {
+	userId, exists := ctx.Get("UserId")
+	id, ok := userId.(uint)
+	if exists == false || !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: You must be logged in to access this resource."})
+		return
+	}
+	channelId, ok := parseChannelId(ctx)
+	if !ok {
+		return
+	}
+
+	var channel models.Channel
+	if err := database.DB.First(&channel, channelId).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Channel not found"})
+		return
+	}
+
+	membership, err := GetChannelMembership(channelId, id)
+	if err != nil && channel.Visibility == models.ChannelPrivate {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Channel not found"})
+		return
+	}
+
+	var members []models.ChannelMember
+	if err := database.DB.Where("channel_id = ?", channelId).Order("joined_at asc").Find(&members).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := ChannelResponse{Channel: channel, Members: int64(len(members))}
+	if membership != nil {
+		response.Role = membership.Role
+	}
+	ctx.JSON(http.StatusOK, gin.H{"channel": response, "members": members})
+}
+
+// UpdateChannel lets the owner change who may join the channel. Switching to
+// protected needs a password unless the channel already had one.
+func UpdateChannel(ctx *gin.Context) {
+	userId, exists := ctx.Get("UserId")
+	id, ok := userId.(uint)
+	if exists == false || !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: You must be logged in to access this resource."})
+		return
+	}
+	channelId, ok := parseChannelId(ctx)
+	if !ok {
+		return
+	}
+
+	var input models.UpdateChannelDto
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data"})
+		return
+	}
+
+	membership, err := GetChannelMembership(channelId, id)
+	if err != nil || membership.Role != models.ChannelRoleOwner {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Only the channel owner can change the channel."})
+		return
+	}
+
+	var channel models.Channel
+	if err := database.DB.First(&channel, channelId).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Channel not found"})
+		return
+	}
+
+	if input.Visibility != models.ChannelProtected && input.Password != "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "A password is required for protected channels only"})
+		return
+	}
+	if input.Visibility == models.ChannelProtected && input.Password == "" && channel.Visibility != models.ChannelProtected {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "A password is required for protected channels only"})
+		return
+	}
+
+	if input.Visibility != models.ChannelProtected {
+		channel.Password = ""
+	} else if input.Password != "" {
+		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+			return
+		}
+		channel.Password = string(hashedPassword)
+	}
+	channel.Visibility = input.Visibility
+
+	if err := database.DB.Model(&channel).Select("visibility", "password").Updates(&channel).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, ChannelResponse{Channel: channel, Role: membership.Role})
+}
+
+func GetChannelMessages(ctx *gin.Context) {
+	userId, exists := ctx.Get("UserId")
+	id, ok := userId.(uint)
+	if exists == false || !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: You must be logged in to access this resource."})
+		return
+	}
+	channelId, ok := parseChannelId(ctx)
+	if !ok {
+		return
+	}
+
+	if _, err := GetChannelMembership(channelId, id); err != nil {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "You are not a member of this channel."})
+		return
+	}
+
+	limit := defaultConversationLimit
+	if value := ctx.Query("limit"); value != "" {
+		var err error
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxConversationLimit {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be between 1 and %d", maxConversationLimit)})
+			return
+		}
+	}
+
+	query := database.DB.Where("channel_id = ?", channelId)
+
+	if before := ctx.Query("before"); before != "" {
+		var cursor models.ChannelMessage
+		if err := query.Session(&gorm.Session{}).First(&cursor, "id = ?", before).Error; err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return
+		}
+		query = query.Where("(created_at, id) < (?, ?)", cursor.CreatedAt, cursor.ID)
+	}
+
+	var messages []models.ChannelMessage
+	if err := query.Order("created_at desc, id desc").Limit(limit + 1).Find(&messages).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	messages, nextCursor := trimPage(messages, limit, func(message models.ChannelMessage) uint { return message.ID })
+
+	ctx.JSON(http.StatusOK, gin.H{"messages": messages, "nextCursor": nextCursor})
+}
+
+func JoinChannel(ctx *gin.Context) {
+	userId, exists := ctx.Get("UserId")
+	id, ok := userId.(uint)
+	if exists == false || !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: You must be logged in to access this resource."})
+		return
+	}
+	channelId, ok := parseChannelId(ctx)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		Password string `json:"password"`
+	}
+	ctx.ShouldBindJSON(&input)
+
+	var channel models.Channel
+	if err := database.DB.First(&channel, channelId).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Channel not found"})
+		return
+	}
+
+	if _, err := GetChannelMembership(channelId, id); err == nil {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "You are already a member of this channel"})
+		return
+	}
+
+	if _, err := GetChannelSanction(channelId, id, models.ChannelBan); err == nil {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "You are banned from this channel."})
+		return
+	}
+
+	switch channel.Visibility {
+	case models.ChannelPrivate:
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Channel not found"})
+		return
+	case models.ChannelProtected:
+		if err := bcrypt.CompareHashAndPassword([]byte(channel.Password), []byte(input.Password)); err != nil {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "Invalid channel password"})
+			return
+		}
+	}
+
+	member := models.ChannelMember{
+		ChannelID: channelId,
+		UserID:    id,
+		Role:      models.ChannelRoleMember,
+		JoinedAt:  time.Now(),
+	}
+	if err := database.DB.Create(&member).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, member)
+}
+
+// LeaveChannel removes the user from the channel. An owner hands the channel
+// over to the oldest admin, or the oldest member, and the last one out
+// deletes it.
+func LeaveChannel(ctx *gin.Context) {
+	userId, exists := ctx.Get("UserId")
+	id, ok := userId.(uint)
+	if exists == false || !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: You must be logged in to access this resource."})
+		return
+	}
+	channelId, ok := parseChannelId(ctx)
+	if !ok {
+		return
+	}
+
+	membership, err := GetChannelMembership(channelId, id)
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "You are not a member of this channel"})
+		return
+	}
+
+	if err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(membership).Error; err != nil {
+			return err
+		}
+		if membership.Role != models.ChannelRoleOwner {
+			return nil
+		}
+
+		var successor models.ChannelMember
+		err := tx.Where("channel_id = ?", channelId).
+			Order("role = 'admin' DESC, joined_at asc").
+			First(&successor).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			if err := tx.Where("channel_id = ?", channelId).Delete(&models.ChannelMessage{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where("channel_id = ?", channelId).Delete(&models.ChannelSanction{}).Error; err != nil {
+				return err
+			}
+			return tx.Delete(&models.Channel{}, channelId).Error
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Model(&successor).Update("role", models.ChannelRoleOwner).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Channel{}).Where("id = ?", channelId).Update("owner_id", successor.UserID).Error
+	}); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"success": "You left the channel"})
+}
+
+// AddChannelMember lets an admin bring someone in, it is the only way into a
+// private channel.
+func AddChannelMember(ctx *gin.Context) {
+	userId, exists := ctx.Get("UserId")
+	id, ok := userId.(uint)
+	if exists == false || !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: You must be logged in to access this resource."})
+		return
+	}
+	channelId, ok := parseChannelId(ctx)
+	if !ok {
+		return
+	}
+
+	var input struct {
+		UserID uint `json:"userId" binding:"required"`
+	}
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data"})
+		return
+	}
+
+	membership, err := GetChannelMembership(channelId, id)
+	if err != nil || channelRoleRank[membership.Role] < channelRoleRank[models.ChannelRoleAdmin] {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Only channel admins can add members."})
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, input.UserID).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	if _, err := GetChannelMembership(channelId, input.UserID); err == nil {
+		ctx.JSON(http.StatusConflict, gin.H{"error": "User is already a member of this channel"})
+		return
+	}
+
+	if _, err := GetChannelSanction(channelId, input.UserID, models.ChannelBan); err == nil {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "User is banned from this channel."})
+		return
+	}
+
+	member := models.ChannelMember{
+		ChannelID: channelId,
+		UserID:    input.UserID,
+		Role:      models.ChannelRoleMember,
+		JoinedAt:  time.Now(),
+	}
+	if err := database.DB.Create(&member).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusCreated, member)
+}
+
+// UpdateChannelMember lets the owner promote members to admin or demote them.
+func UpdateChannelMember(ctx *gin.Context) {
+	userId, exists := ctx.Get("UserId")
+	id, ok := userId.(uint)
+	if exists == false || !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: You must be logged in to access this resource."})
+		return
+	}
+	channelId, ok := parseChannelId(ctx)
+	if !ok {
+		return
+	}
+	targetId, err := strconv.ParseUint(ctx.Param("userId"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format of user id"})
+		return
+	}
+
+	var input struct {
+		Role string `json:"role" binding:"required,oneof=admin member"`
+	}
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data"})
+		return
+	}
+
+	membership, err := GetChannelMembership(channelId, id)
+	if err != nil || membership.Role != models.ChannelRoleOwner {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Only the channel owner can change roles."})
+		return
+	}
+
+	target, err := GetChannelMembership(channelId, uint(targetId))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Member not found"})
+		return
+	}
+	if target.UserID == id {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "You cannot change your own role"})
+		return
+	}
+
+	if err := database.DB.Model(target).Update("role", input.Role).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, target)
+}
+
+// RemoveChannelMember kicks someone out, only over members of a lower role.
+func RemoveChannelMember(ctx *gin.Context) {
+	userId, exists := ctx.Get("UserId")
+	id, ok := userId.(uint)
+	if exists == false || !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: You must be logged in to access this resource."})
+		return
+	}
+	channelId, ok := parseChannelId(ctx)
+	if !ok {
+		return
+	}
+	targetId, err := strconv.ParseUint(ctx.Param("userId"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format of user id"})
+		return
+	}
+
+	membership, err := GetChannelMembership(channelId, id)
+	if err != nil {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "You are not a member of this channel."})
+		return
+	}
+
+	target, err := GetChannelMembership(channelId, uint(targetId))
+	if err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Member not found"})
+		return
+	}
+	if channelRoleRank[membership.Role] < channelRoleRank[models.ChannelRoleAdmin] ||
+		channelRoleRank[membership.Role] <= channelRoleRank[target.Role] {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "You cannot remove this member."})
+		return
+	}
+
+	if err := database.DB.Delete(target).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"success": "Member removed"})
+}
+
+func GetChannelSanctions(ctx *gin.Context) {
+	userId, exists := ctx.Get("UserId")
+	id, ok := userId.(uint)
+	if exists == false || !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: You must be logged in to access this resource."})
+		return
+	}
+	channelId, ok := parseChannelId(ctx)
+	if !ok {
+		return
+	}
+
+	membership, err := GetChannelMembership(channelId, id)
+	if err != nil || channelRoleRank[membership.Role] < channelRoleRank[models.ChannelRoleAdmin] {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Only channel admins can see the sanctions."})
+		return
+	}
+
+	sanctions := []models.ChannelSanction{}
+	if err := database.DB.
+		Where("channel_id = ? AND (until IS NULL OR until > ?)", channelId, time.Now()).
+		Order("created_at desc").
+		Find(&sanctions).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, sanctions)
+}
+
+func BanChannelMember(ctx *gin.Context) {
+	sanctionChannelMember(ctx, models.ChannelBan)
+}
+
+func UnbanChannelMember(ctx *gin.Context) {
+	liftChannelSanction(ctx, models.ChannelBan)
+}
+
+func MuteChannelMember(ctx *gin.Context) {
+	sanctionChannelMember(ctx, models.ChannelMute)
+}
+
+func UnmuteChannelMember(ctx *gin.Context) {
+	liftChannelSanction(ctx, models.ChannelMute)
+}
+
+// authorizeChannelSanction answers the request itself unless the user is an
+// admin of the channel ranked above the target, who does not have to be a
+// member so that someone can be banned before joining.
+func authorizeChannelSanction(ctx *gin.Context) (uint, uint, uint, bool) {
+	userId, exists := ctx.Get("UserId")
+	id, ok := userId.(uint)
+	if exists == false || !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: You must be logged in to access this resource."})
+		return 0, 0, 0, false
+	}
+	channelId, ok := parseChannelId(ctx)
+	if !ok {
+		return 0, 0, 0, false
+	}
+	targetId, err := strconv.ParseUint(ctx.Param("userId"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format of user id"})
+		return 0, 0, 0, false
+	}
+
+	membership, err := GetChannelMembership(channelId, id)
+	if err != nil || channelRoleRank[membership.Role] < channelRoleRank[models.ChannelRoleAdmin] {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Only channel admins can sanction members."})
+		return 0, 0, 0, false
+	}
+
+	targetRole := models.ChannelRoleMember
+	if target, err := GetChannelMembership(channelId, uint(targetId)); err == nil {
+		targetRole = target.Role
+	}
+	if uint(targetId) == id || channelRoleRank[membership.Role] <= channelRoleRank[targetRole] {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "You cannot sanction this member."})
+		return 0, 0, 0, false
+	}
+	return id, channelId, uint(targetId), true
+}
+
+// sanctionChannelMember replaces any running sanction of the same kind, a ban
+// also removes the user from the channel.
+func sanctionChannelMember(ctx *gin.Context, kind string) {
+	id, channelId, targetId, ok := authorizeChannelSanction(ctx)
+	if !ok {
+		return
+	}
+
+	var input models.ChannelSanctionDto
+	if err := ctx.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data"})
+		return
+	}
+	if input.Until != nil && !input.Until.After(time.Now()) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "The sanction must end in the future"})
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, targetId).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	sanction := models.ChannelSanction{
+		ChannelID: channelId,
+		UserID:    targetId,
+		Kind:      kind,
+		IssuedBy:  id,
+		Until:     input.Until,
+	}
+	if err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("channel_id = ? AND user_id = ? AND kind = ?", channelId, targetId, kind).Delete(&models.ChannelSanction{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(&sanction).Error; err != nil {
+			return err
+		}
+		if kind != models.ChannelBan {
+			return nil
+		}
+		return tx.Where("channel_id = ? AND user_id = ?", channelId, targetId).Delete(&models.ChannelMember{}).Error
+	}); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusCreated, sanction)
+}
+
+func liftChannelSanction(ctx *gin.Context, kind string) {
+	_, channelId, targetId, ok := authorizeChannelSanction(ctx)
+	if !ok {
+		return
+	}
+
+	result := database.DB.Where("channel_id = ? AND user_id = ? AND kind = ?", channelId, targetId, kind).Delete(&models.ChannelSanction{})
+	if result.Error != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": result.Error.Error()})
+		return
+	}
+	if result.RowsAffected == 0 {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Sanction not found"})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"success": "Sanction lifted"})
+}
+
+// SaveChannelMessage is the internal route used by the hub, it answers with
+// the stored message and the members to relay it to.
+func SaveChannelMessage(ctx *gin.Context) {
+	var message models.ChannelMessage
+	if err := ctx.ShouldBindJSON(&message); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data"})
+		return
+	}
+	message.ID = 0
+	message.CreatedAt = time.Now()
+
+	if _, err := GetChannelMembership(message.ChannelID, message.SenderID); err != nil {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "You are not a member of this channel."})
+		return
+	}
+
+	if _, err := GetChannelSanction(message.ChannelID, message.SenderID, models.ChannelMute); err == nil {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "You are muted in this channel."})
+		return
+	}
+
+	if err := database.DB.Create(&message).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	members, err := GetChannelMemberIds(message.ChannelID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusCreated, gin.H{"message": message, "members": members})
+}
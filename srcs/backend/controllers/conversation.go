@@ -3,15 +3,53 @@ package controllers
 import (
 	"api/database"
 	"api/models"
+	"api/utils"
+	"fmt"
+	"log"
 	"net/http"
+	"slices"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
+const (
+	defaultConversationLimit = 50
+	maxConversationLimit     = 100
+	maxMissedMessages        = 500
+)
+
+// Messages can be edited or deleted by their sender for this long after they
+// were sent, MESSAGE_EDIT_WINDOW overrides it.
+var messageEditWindow = utils.DurationFromEnv("MESSAGE_EDIT_WINDOW", 15*time.Minute)
+
+type MessageChangeEvent struct {
+	Type       string     `json:"type"`
+	MessageID  uint       `json:"messageId"`
+	SenderID   uint       `json:"senderID"`
+	ReceiverID uint       `json:"receiverID"`
+	Data       string     `json:"data"`
+	EditedAt   *time.Time `json:"editedAt,omitempty"`
+	DeletedAt  *time.Time `json:"deletedAt,omitempty"`
+}
+
+// ReceiptEvent mirrors the CHAT_READ receipts the hub sends, so reads made
+// over HTTP show up the same way.
+type ReceiptEvent struct {
+	Type      string `json:"type"`
+	UserID    uint   `json:"userId"`
+	FriendID  uint   `json:"friendId"`
+	MessageID uint   `json:"messageId"`
+}
+
 func Conversation(ctx *gin.RouterGroup) {
+	ctx.GET("/unread", GetUnreadCounts)
 	ctx.GET("/:friendId", GetConversation)
-	ctx.POST("/add", SaveNewMessage)
+	ctx.POST("/:friendId/read", ReadConversation)
+	ctx.PUT("/message/:id", EditMessage)
+	ctx.DELETE("/message/:id", DeleteMessage)
 }
 
 func GetConversation(ctx *gin.Context) {
@@ -29,14 +67,75 @@ func GetConversation(ctx *gin.Context) {
 		return
 	}
 
+	// A blocked thread is hidden as if it never existed.
+	blocked, err := IsBlocked(id, uint(friendId))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if blocked {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Conversation not found"})
+		return
+	}
+
+	friends, err := AreFriends(id, uint(friendId))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !friends {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "You can only read conversations with your friends."})
+		return
+	}
+
+	limit := defaultConversationLimit
+	if value := ctx.Query("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxConversationLimit {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("limit must be between 1 and %d", maxConversationLimit)})
+			return
+		}
+	}
+
+	query := database.DB.Where("(sender_id = ? AND receiver_id = ?) OR (sender_id = ? AND receiver_id = ?)", id, friendId, friendId, id)
+
+	if before := ctx.Query("before"); before != "" {
+		var cursor models.Message
+		if err := query.Session(&gorm.Session{}).First(&cursor, "id = ?", before).Error; err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid cursor"})
+			return
+		}
+		query = query.Where("(created_at, id) < (?, ?)", cursor.CreatedAt, cursor.ID)
+	}
+
+	// Newest page first, one extra row tells whether an older page exists.
 	var conversation []models.Message
-	if err := database.DB.Find(&conversation, "(sender_id = ? OR sender_id = ?) AND (receiver_id = ? OR receiver_id = ?)", id, friendId, id, friendId).Error; err != nil {
+	if err := query.Order("created_at desc, id desc").Limit(limit + 1).Find(&conversation).Error; err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	ctx.JSON(http.StatusOK, gin.H{"conversation": conversation})
+
+	conversation, nextCursor := trimPage(conversation, limit, func(message models.Message) uint { return message.ID })
+
+	ctx.JSON(http.StatusOK, gin.H{"conversation": conversation, "nextCursor": nextCursor})
+}
+
+// trimPage takes a page fetched newest first with one row more than limit,
+// keeps limit rows in chronological order and returns the id to pass as the
+// before cursor of the next older page, nil when there is none.
+func trimPage[T any](rows []T, limit int, id func(T) uint) ([]T, *uint) {
+	var nextCursor *uint
+	if len(rows) > limit {
+		rows = rows[:limit]
+		oldest := id(rows[limit-1])
+		nextCursor = &oldest
+	}
+	slices.Reverse(rows)
+	return rows, nextCursor
 }
 
+// SaveNewMessage is the internal route used by the websocket service, the
+// sender was authenticated by the hub so it is taken from the body.
 func SaveNewMessage(ctx *gin.Context) {
 	var message models.Message
 	err := ctx.ShouldBindJSON(&message)
@@ -44,10 +143,296 @@ func SaveNewMessage(ctx *gin.Context) {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data"})
 		return
 	}
+	message.ID = 0
+	message.ReadAt = nil
+	message.EditedAt = nil
+	message.DeletedAt = nil
+	message.EditHistory = nil
+
+	blocked, err := IsBlocked(message.SenderID, message.ReceiverID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if blocked {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "You cannot message this user."})
+		return
+	}
+
+	friends, err := AreFriends(message.SenderID, message.ReceiverID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !friends {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "You can only send messages to your friends."})
+		return
+	}
 
 	if err := database.DB.Create(&message).Error; err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	ctx.JSON(http.StatusCreated, gin.H{})
+	ctx.JSON(http.StatusCreated, message)
+}
+
+// findEditableMessage loads a message the authenticated user sent and may
+// still change, otherwise it answers the request itself and returns nil.
+func findEditableMessage(ctx *gin.Context) *models.Message {
+	messageId, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format of message id"})
+		return nil
+	}
+
+	userId, exists := ctx.Get("UserId")
+	id, ok := userId.(uint)
+	if exists == false || !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: You must be logged in to access this resource."})
+		return nil
+	}
+
+	var message models.Message
+	if err := database.DB.First(&message, "id = ? AND deleted_at IS NULL", messageId).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Message not found"})
+		return nil
+	}
+	if message.SenderID != id {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "You can only change your own messages."})
+		return nil
+	}
+	if time.Since(message.CreatedAt) > messageEditWindow {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "This message can no longer be changed."})
+		return nil
+	}
+	return &message
+}
+
+// notifyMessageChange tells both participants about the change, the request
+// has already succeeded so a failure is only logged.
+func notifyMessageChange(eventType string, message *models.Message) {
+	event := MessageChangeEvent{
+		Type:       eventType,
+		MessageID:  message.ID,
+		SenderID:   message.SenderID,
+		ReceiverID: message.ReceiverID,
+		Data:       message.Content,
+		EditedAt:   message.EditedAt,
+		DeletedAt:  message.DeletedAt,
+	}
+	if err := utils.PushEvent([]uint{message.SenderID, message.ReceiverID}, event); err != nil {
+		log.Printf("error pushing %s for message %d: %v", eventType, message.ID, err)
+	}
+}
+
+func EditMessage(ctx *gin.Context) {
+	var input struct {
+		Content string `json:"content" binding:"required"`
+	}
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data"})
+		return
+	}
+
+	message := findEditableMessage(ctx)
+	if message == nil {
+		return
+	}
+
+	now := time.Now()
+	message.EditHistory = append(message.EditHistory, models.MessageRevision{
+		Content:  message.Content,
+		EditedAt: now,
+	})
+	message.Content = input.Content
+	message.EditedAt = &now
+
+	if err := database.DB.Model(message).Select("content", "edited_at", "edit_history").Updates(message).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	notifyMessageChange("CHAT_EDITED", message)
+	ctx.JSON(http.StatusOK, message)
+}
+
+func DeleteMessage(ctx *gin.Context) {
+	message := findEditableMessage(ctx)
+	if message == nil {
+		return
+	}
+
+	// The previous versions go with the content, nothing of it is kept.
+	now := time.Now()
+	message.Content = ""
+	message.EditHistory = nil
+	message.DeletedAt = &now
+
+	if err := database.DB.Model(message).Select("content", "edit_history", "deleted_at").Updates(message).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	notifyMessageChange("CHAT_DELETED", message)
+	ctx.JSON(http.StatusOK, message)
+}
+
+// MarkConversationRead flags every message the friend sent to the reader up
+// to the given id as read, and delivered if it was not yet.
+func MarkConversationRead(readerId uint, friendId uint, upTo uint) (int64, error) {
+	now := time.Now()
+	result := database.DB.Model(&models.Message{}).
+		Where("sender_id = ? AND receiver_id = ? AND id <= ? AND read_at IS NULL", friendId, readerId, upTo).
+		Updates(map[string]interface{}{
+			"read_at":      now,
+			"delivered_at": gorm.Expr("COALESCE(delivered_at, ?)", now),
+		})
+	return result.RowsAffected, result.Error
+}
+
+func ReadConversation(ctx *gin.Context) {
+	friend := ctx.Param("friendId")
+	friendId, err := strconv.ParseUint(friend, 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format of friend id"})
+		return
+	}
+
+	userId, exists := ctx.Get("UserId")
+	id, ok := userId.(uint)
+	if exists == false || !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: You must be logged in to access this resource."})
+		return
+	}
+
+	var input struct {
+		UpTo uint `json:"upTo" binding:"required"`
+	}
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data"})
+		return
+	}
+
+	updated, err := MarkConversationRead(id, uint(friendId), input.UpTo)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if updated > 0 {
+		notifyConversationRead(id, uint(friendId), input.UpTo)
+	}
+	ctx.JSON(http.StatusOK, gin.H{"updated": updated})
+}
+
+// notifyConversationRead tells the friend their messages were read, the
+// request has already succeeded so a failure is only logged.
+func notifyConversationRead(readerId uint, friendId uint, upTo uint) {
+	event := ReceiptEvent{
+		Type:      "CHAT_READ",
+		UserID:    readerId,
+		FriendID:  friendId,
+		MessageID: upTo,
+	}
+	if err := utils.PushEvent([]uint{friendId}, event); err != nil {
+		log.Printf("error pushing CHAT_READ to user %d: %v", friendId, err)
+	}
+}
+
+// ReadConversationInternal is called by the hub when a client acknowledges
+// messages over the websocket.
+func ReadConversationInternal(ctx *gin.Context) {
+	var input struct {
+		UserID   uint `json:"userId" binding:"required"`
+		FriendID uint `json:"friendId" binding:"required"`
+		UpTo     uint `json:"upTo" binding:"required"`
+	}
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data"})
+		return
+	}
+
+	updated, err := MarkConversationRead(input.UserID, input.FriendID, input.UpTo)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"updated": updated})
+}
+
+func GetUnreadCounts(ctx *gin.Context) {
+	userId, exists := ctx.Get("UserId")
+	id, ok := userId.(uint)
+	if exists == false || !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: You must be logged in to access this resource."})
+		return
+	}
+
+	var unread []struct {
+		FriendID      uint `json:"friendId"`
+		Count         int  `json:"count"`
+		LastMessageID uint `json:"lastMessageId"`
+	}
+	if err := database.DB.Raw(`
+		SELECT sender_id AS friend_id, COUNT(*) AS count, MAX(id) AS last_message_id
+		FROM messages
+		WHERE receiver_id = ? AND read_at IS NULL AND deleted_at IS NULL
+		GROUP BY sender_id
+		`, id).Scan(&unread).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, unread)
+}
+
+// GetMissedMessages lets the hub replay every message of the user newer than
+// the last one the client saw, those received are now delivered. Threads
+// hidden by a block are left out, as in GetConversation.
+func GetMissedMessages(ctx *gin.Context) {
+	userId, err := strconv.ParseUint(ctx.Param("userId"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format of user id"})
+		return
+	}
+	after, err := strconv.ParseUint(ctx.DefaultQuery("after", "0"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format of message id"})
+		return
+	}
+
+	var messages []models.Message
+	if err := database.DB.
+		Where("(sender_id = ? OR receiver_id = ?) AND id > ? AND deleted_at IS NULL", userId, userId, after).
+		Where(`NOT EXISTS (
+			SELECT 1 FROM blocks
+			WHERE (blocks.blocker_id = messages.sender_id AND blocks.blocked_id = messages.receiver_id)
+			OR (blocks.blocker_id = messages.receiver_id AND blocks.blocked_id = messages.sender_id)
+		)`).
+		Order("id asc").
+		Limit(maxMissedMessages + 1).
+		Find(&messages).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	hasMore := len(messages) > maxMissedMessages
+	if hasMore {
+		messages = messages[:maxMissedMessages]
+	}
+
+	delivered := []uint{}
+	for _, message := range messages {
+		if message.ReceiverID == uint(userId) && message.DeliveredAt == nil {
+			delivered = append(delivered, message.ID)
+		}
+	}
+	if len(delivered) > 0 {
+		if err := database.DB.Model(&models.Message{}).
+			Where("id IN ? AND delivered_at IS NULL", delivered).
+			Update("delivered_at", time.Now()).Error; err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"messages": messages, "delivered": delivered, "hasMore": hasMore})
 }
@@ -0,0 +1,90 @@
+package controllers
+
+import (
+	"api/models"
+	"slices"
+	"testing"
+)
+
+func messageId(message models.Message) uint { return message.ID }
+
+func messageIds(messages []models.Message) []uint {
+	var ids []uint
+	for _, message := range messages {
+		ids = append(ids, message.ID)
+	}
+	return ids
+}
+
+func TestTrimPage(t *testing.T) {
+	newestFirst := []models.Message{{ID: 9}, {ID: 7}, {ID: 4}, {ID: 2}}
+
+	tests := []struct {
+		name       string
+		rows       []models.Message
+		limit      int
+		wantIds    []uint
+		wantCursor uint
+	}{
+		{name: "empty", limit: 3},
+		{name: "short page", rows: newestFirst[:2], limit: 3, wantIds: []uint{7, 9}},
+		{name: "exactly limit rows", rows: newestFirst[:3], limit: 3, wantIds: []uint{4, 7, 9}},
+		{name: "older page left", rows: newestFirst, limit: 3, wantIds: []uint{4, 7, 9}, wantCursor: 4},
+		{name: "single row pages", rows: newestFirst[:2], limit: 1, wantIds: []uint{9}, wantCursor: 9},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, cursor := trimPage(slices.Clone(tt.rows), tt.limit, messageId)
+			if ids := messageIds(page); !slices.Equal(ids, tt.wantIds) {
+				t.Errorf("page %v, want %v", ids, tt.wantIds)
+			}
+			switch {
+			case tt.wantCursor == 0 && cursor != nil:
+				t.Errorf("cursor %d, want none", *cursor)
+			case tt.wantCursor != 0 && cursor == nil:
+				t.Errorf("no cursor, want %d", tt.wantCursor)
+			case cursor != nil && *cursor != tt.wantCursor:
+				t.Errorf("cursor %d, want %d", *cursor, tt.wantCursor)
+			}
+		})
+	}
+}
+
+// Following the cursors must walk the whole history once, like the
+// handlers do with "(created_at, id) < cursor ORDER BY created_at desc, id desc".
+func TestTrimPageWalksHistory(t *testing.T) {
+	var history []models.Message
+	for id := uint(1); id <= 11; id++ {
+		history = append(history, models.Message{ID: id})
+	}
+
+	fetch := func(before *uint, limit int) []models.Message {
+		var rows []models.Message
+		for i := len(history) - 1; i >= 0 && len(rows) < limit+1; i-- {
+			if before == nil || history[i].ID < *before {
+				rows = append(rows, history[i])
+			}
+		}
+		return rows
+	}
+
+	const limit = 4
+	var seen []models.Message
+	var cursor *uint
+	for pages := 0; ; pages++ {
+		if pages > len(history) {
+			t.Fatalf("the cursors never reached the oldest message")
+		}
+		var page []models.Message
+		page, cursor = trimPage(fetch(cursor, limit), limit, messageId)
+		seen = append(page, seen...)
+		if cursor == nil {
+			break
+		}
+	}
+
+	if !slices.Equal(messageIds(seen), messageIds(history)) {
+		t.Errorf("walked %v, want %v", messageIds(seen), messageIds(history))
+	}
+}
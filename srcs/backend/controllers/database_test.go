@@ -0,0 +1,59 @@
+package controllers
+
+import (
+	"api/database"
+	"api/models"
+	"errors"
+	"os"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+var errRollback = errors.New("rollback")
+
+// withTestDB runs the test in a transaction of the TEST_DATABASE_DSN
+// database, rolled back once it ends. database.DB points to the transaction
+// meanwhile so handlers can be called as they are. Tests are skipped when no
+// database is given, e.g. TEST_DATABASE_DSN="host=localhost user=postgres
+// password=postgres dbname=test sslmode=disable".
+func withTestDB(t *testing.T, test func(tx *gorm.DB)) {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN is not set")
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("connecting to the test database: %v", err)
+	}
+	if err := db.AutoMigrate(&models.User{}, &models.Session{}, &models.RefreshToken{}, &models.RevokedToken{}, &models.UserAchievement{}); err != nil {
+		t.Fatalf("migrating the test database: %v", err)
+	}
+
+	previous := database.DB
+	defer func() { database.DB = previous }()
+
+	err = db.Transaction(func(tx *gorm.DB) error {
+		database.DB = tx
+		test(tx)
+		return errRollback
+	})
+	if err != nil && !errors.Is(err, errRollback) {
+		t.Fatalf("test transaction: %v", err)
+	}
+}
+
+func createTestUser(t *testing.T, tx *gorm.DB, nickname string) models.User {
+	t.Helper()
+	user := models.User{Nickname: nickname, DisplayName: nickname, Password: "unused"}
+	if err := tx.Create(&user).Error; err != nil {
+		t.Fatalf("creating user %s: %v", nickname, err)
+	}
+	return user
+}
@@ -18,6 +18,16 @@ func FriendShip(ctx *gin.RouterGroup) {
 	ctx.DELETE("/delete/:friendId", RemoveFriend)
 }
 
+// AreFriends tells whether both users accepted each other, whoever sent the
+// request.
+func AreFriends(userId uint, friendId uint) (bool, error) {
+	var count int64
+	err := database.DB.Model(&models.FriendShip{}).
+		Where("((user_id = ? AND friend_id = ?) OR (user_id = ? AND friend_id = ?)) AND mutual_friends = true", userId, friendId, friendId, userId).
+		Count(&count).Error
+	return count > 0, err
+}
+
 func RemoveFriend(ctx *gin.Context) {
 	friend := ctx.Param("friendId")
 	friendId, err := strconv.ParseUint(friend, 10, 64)
@@ -137,8 +147,18 @@ func AddFriend(ctx *gin.Context) {
 		return
 	}
 
+	blocked, err := IsBlocked(id, friendUser.ID)
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if blocked {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "You cannot add this user as a friend."})
+		return
+	}
+
 	var existingFriend models.FriendShip
-	err := database.DB.First(&existingFriend, "(user_id = ? AND friend_id = ?) OR (user_id = ? AND friend_id = ?)", id, friendUser.ID, friendUser.ID, id).Error
+	err = database.DB.First(&existingFriend, "(user_id = ? AND friend_id = ?) OR (user_id = ? AND friend_id = ?)", id, friendUser.ID, friendUser.ID, id).Error
 	if err == nil {
 		if existingFriend.MutualFriends {
 			ctx.JSON(http.StatusConflict, gin.H{"error": "You are already friends with this user."})
@@ -6,8 +6,11 @@ import (
 	"api/models"
 	"api/database"
     "api/prometheus"
+    "api/utils"
     "gorm.io/gorm"
+    "gorm.io/gorm/clause"
 	"fmt"
+	"strconv"
 )
 
 
@@ -17,6 +20,14 @@ type GameHistoryInput struct {
     WinnerID  uint64 `json:"winner_id"`
     Score1    int    `json:"Score1"`
     Score2    int    `json:"Score2"`
+    IsTournamentGame bool `json:"is_tournament_game"`
+    IsGameMode       bool `json:"is_game_mode"`
+    Duration  float64 `json:"duration"`
+    Player1Boosts int `json:"player1_boosts"`
+    Player2Boosts int `json:"player2_boosts"`
+    Replay    *models.GameReplay `json:"replay"`
+    Rules     models.GameRules `json:"rules"`
+    Forfeit   bool    `json:"forfeit"`
 }
 
 func SaveGameHistory(c *gin.Context) {
@@ -32,7 +43,13 @@ func SaveGameHistory(c *gin.Context) {
         return
     }
 
-    fmt.Printf("Successfully bound input: %+v\n", input)
+    if input.WinnerID != input.Player1ID && input.WinnerID != input.Player2ID {
+        c.JSON(http.StatusBadRequest, gin.H{
+            "error": "Invalid input data",
+            "details": "The winner must be one of the players",
+        })
+        return
+    }
 
     gameHistory := models.GameHistory{
         Player1ID: input.Player1ID,
@@ -40,9 +57,66 @@ func SaveGameHistory(c *gin.Context) {
         WinnerID:  input.WinnerID,
         Score1:    input.Score1,
         Score2:    input.Score2,
+        IsTournamentGame: input.IsTournamentGame,
+        IsGameMode: input.IsGameMode,
+        Duration:  input.Duration,
+        Player1Boosts: input.Player1Boosts,
+        Player2Boosts: input.Player2Boosts,
+        Rules:     input.Rules,
+        Forfeit:   input.Forfeit,
     }
 
-    if err := database.DB.Create(&gameHistory).Error; err != nil {
+    // Both ratings are updated with the history row, players are locked so
+    // two games ending together cannot overwrite each other's update. Rows
+    // are locked by ascending id so two saves never wait on each other.
+    err := database.DB.Transaction(func(tx *gorm.DB) error {
+        var player1, player2 models.User
+        first, second := &player1, &player2
+        firstId, secondId := input.Player1ID, input.Player2ID
+        if secondId < firstId {
+            first, second = second, first
+            firstId, secondId = secondId, firstId
+        }
+        if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(first, firstId).Error; err != nil {
+            return err
+        }
+        if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(second, secondId).Error; err != nil {
+            return err
+        }
+
+        rating1 := utils.Rating{Value: player1.Rating, Deviation: player1.RatingDeviation}
+        rating2 := utils.Rating{Value: player2.Rating, Deviation: player2.RatingDeviation}
+        var new1, new2 utils.Rating
+        if input.WinnerID == input.Player1ID {
+            new1, new2 = utils.RateGame(rating1, rating2, input.IsTournamentGame)
+        } else {
+            new2, new1 = utils.RateGame(rating2, rating1, input.IsTournamentGame)
+        }
+
+        gameHistory.Player1RatingBefore = rating1.Value
+        gameHistory.Player1RatingAfter = new1.Value
+        gameHistory.Player2RatingBefore = rating2.Value
+        gameHistory.Player2RatingAfter = new2.Value
+
+        if err := tx.Model(&player1).Updates(map[string]interface{}{"rating": new1.Value, "rating_deviation": new1.Deviation}).Error; err != nil {
+            return err
+        }
+        if err := tx.Model(&player2).Updates(map[string]interface{}{"rating": new2.Value, "rating_deviation": new2.Deviation}).Error; err != nil {
+            return err
+        }
+        if err := tx.Create(&gameHistory).Error; err != nil {
+            return err
+        }
+        if input.Replay != nil {
+            input.Replay.ID = 0
+            input.Replay.GameHistoryID = gameHistory.ID
+            if err := tx.Create(input.Replay).Error; err != nil {
+                return err
+            }
+        }
+        return RecordLeaderboardGame(tx, &gameHistory)
+    })
+    if err != nil {
         fmt.Printf("DB error: %v\n", err)
         c.JSON(http.StatusInternalServerError, gin.H{
             "error": "Failed to save game history",
@@ -56,6 +130,9 @@ func SaveGameHistory(c *gin.Context) {
         "data": gameHistory,
     })
     prometheus.IncrementPlayedGames()
+    // Achievements are pushed through the websocket service, which must get
+    // its answer first.
+    go EvaluateGameAchievements(&gameHistory)
 }
 
 
@@ -116,4 +193,34 @@ func GetUserGameHistory(c *gin.Context) {
     c.JSON(http.StatusOK, gin.H{
         "data": response,
     })
-}
\ No newline at end of file
+}
+
+// GetGameReplay returns the game with what is needed to simulate it again.
+func GetGameReplay(c *gin.Context) {
+    gameId, err := strconv.ParseUint(c.Param("id"), 10, 64)
+    if err != nil {
+        c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format of game id"})
+        return
+    }
+
+    var game models.GameHistory
+    if err := database.DB.First(&game, gameId).Error; err != nil {
+        c.JSON(http.StatusNotFound, gin.H{"error": "Game not found"})
+        return
+    }
+
+    var replay models.GameReplay
+    if err := database.DB.Where("game_history_id = ?", game.ID).First(&replay).Error; err != nil {
+        if err == gorm.ErrRecordNotFound {
+            c.JSON(http.StatusNotFound, gin.H{"error": "No replay was recorded for this game"})
+            return
+        }
+        c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+        return
+    }
+
+    c.JSON(http.StatusOK, gin.H{
+        "game":   game,
+        "replay": replay,
+    })
+}
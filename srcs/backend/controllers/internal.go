@@ -0,0 +1,79 @@
+package controllers
+
+import (
+	"api/database"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Internal registers the routes only our other services may call, the group
+// is guarded by middleware.ServiceGuard().
+func Internal(ctx *gin.RouterGroup) {
+	ctx.POST("/conversation/add", SaveNewMessage)
+	ctx.POST("/conversation/read", ReadConversationInternal)
+	ctx.GET("/conversation/missed/:userId", GetMissedMessages)
+	ctx.POST("/channels/message", SaveChannelMessage)
+	ctx.POST("/game-history", SaveGameHistory)
+	ctx.GET("/relations/:userId/:targetId", GetRelation)
+	ctx.GET("/sanctions/:userId", GetUserSanctions)
+	ctx.GET("/players/:userId/stats", GetPlayerStats)
+	ctx.POST("/tournaments/result", RecordTournamentResult)
+}
+
+// GetRelation tells the hub how two users relate before it relays ephemeral
+// events between them.
+func GetRelation(ctx *gin.Context) {
+	userId, err := strconv.ParseUint(ctx.Param("userId"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format of user id"})
+		return
+	}
+	targetId, err := strconv.ParseUint(ctx.Param("targetId"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format of target id"})
+		return
+	}
+
+	friends, err := AreFriends(uint(userId), uint(targetId))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	blocked, err := IsBlocked(uint(userId), uint(targetId))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"friends": friends, "blocked": blocked})
+}
+
+// GetPlayerStats gives the hub what it needs to match players of the same
+// level.
+func GetPlayerStats(ctx *gin.Context) {
+	userId, err := strconv.ParseUint(ctx.Param("userId"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format of user id"})
+		return
+	}
+
+	var stats struct {
+		Games int64 `json:"games"`
+		Wins  int64 `json:"wins"`
+	}
+	if err := database.DB.Raw(`
+		SELECT COUNT(*) AS games, COUNT(*) FILTER (WHERE winner_id = ?) AS wins
+		FROM game_histories
+		WHERE (player1_id = ? OR player2_id = ?) AND deleted_at IS NULL
+		`, userId, userId, userId).Scan(&stats).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	winRate := 0.5
+	if stats.Games > 0 {
+		winRate = float64(stats.Wins) / float64(stats.Games)
+	}
+	ctx.JSON(http.StatusOK, gin.H{"games": stats.Games, "wins": stats.Wins, "winRate": winRate})
+}
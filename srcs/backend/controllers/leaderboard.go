@@ -0,0 +1,171 @@
+package controllers
+
+import (
+	"api/database"
+	"api/models"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	leaderboardDefaultLimit = 20
+	leaderboardMaxLimit     = 100
+)
+
+// leaderboardPeriodStart returns the start of the period containing t, weeks
+// start on monday and everything is counted in UTC.
+func leaderboardPeriodStart(period string, t time.Time) (time.Time, bool) {
+	t = t.UTC()
+	switch period {
+	case models.LeaderboardAllTime:
+		return time.Time{}, true
+	case models.LeaderboardMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC), true
+	case models.LeaderboardWeek:
+		day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7), true
+	}
+	return time.Time{}, false
+}
+
+func recordLeaderboardResult(tx *gorm.DB, userId uint, playedAt time.Time, won bool, pointDifference int) error {
+	wins, losses := 0, 0
+	if won {
+		wins = 1
+	} else {
+		losses = 1
+	}
+
+	for _, period := range []string{models.LeaderboardAllTime, models.LeaderboardMonth, models.LeaderboardWeek} {
+		start, _ := leaderboardPeriodStart(period, playedAt)
+		entry := models.LeaderboardEntry{
+			UserID:          userId,
+			Period:          period,
+			PeriodStart:     start,
+			Wins:            int64(wins),
+			Losses:          int64(losses),
+			PointDifference: int64(pointDifference),
+		}
+		if err := tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "user_id"}, {Name: "period"}, {Name: "period_start"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"wins":             gorm.Expr("leaderboard_entries.wins + ?", wins),
+				"losses":           gorm.Expr("leaderboard_entries.losses + ?", losses),
+				"point_difference": gorm.Expr("leaderboard_entries.point_difference + ?", pointDifference),
+			}),
+		}).Create(&entry).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RecordLeaderboardGame adds a finished game to both players' entries, it
+// runs in the transaction saving the game so the cache never drifts.
+func RecordLeaderboardGame(tx *gorm.DB, game *models.GameHistory) error {
+	difference := game.Score1 - game.Score2
+	if err := recordLeaderboardResult(tx, uint(game.Player1ID), game.CreatedAt, game.WinnerID == game.Player1ID, difference); err != nil {
+		return err
+	}
+	return recordLeaderboardResult(tx, uint(game.Player2ID), game.CreatedAt, game.WinnerID == game.Player2ID, -difference)
+}
+
+// BackfillLeaderboard fills the cache from the game history when it is
+// empty, which is the first startup with the table. Every later game updates
+// it as it is saved, so the history is never scanned again.
+func BackfillLeaderboard() error {
+	var entries int64
+	if err := database.DB.Model(&models.LeaderboardEntry{}).Count(&entries).Error; err != nil {
+		return err
+	}
+	if entries > 0 {
+		return nil
+	}
+
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		var games []models.GameHistory
+		if err := tx.Find(&games).Error; err != nil {
+			return err
+		}
+		for i := range games {
+			if err := RecordLeaderboardGame(tx, &games[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetLeaderboard ranks players by wins over the period, ties are broken by
+// win rate, then by point difference and finally by user id so pages stay
+// stable. A player with few games and a perfect win rate therefore ranks
+// below one who won more games.
+func GetLeaderboard(ctx *gin.Context) {
+	period := ctx.DefaultQuery("period", models.LeaderboardAllTime)
+	start, ok := leaderboardPeriodStart(period, time.Now())
+	if !ok {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid period, expected all, month or week"})
+		return
+	}
+
+	limit, err := strconv.Atoi(ctx.DefaultQuery("limit", strconv.Itoa(leaderboardDefaultLimit)))
+	if err != nil || limit <= 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid limit"})
+		return
+	}
+	limit = min(limit, leaderboardMaxLimit)
+	offset, err := strconv.Atoi(ctx.DefaultQuery("offset", "0"))
+	if err != nil || offset < 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid offset"})
+		return
+	}
+
+	query := database.DB.Table("leaderboard_entries l").
+		Joins(`JOIN "users" u ON u.id = l.user_id`).
+		Where("l.period = ? AND l.period_start = ?", period, start)
+
+	switch ctx.DefaultQuery("scope", "global") {
+	case "global":
+	case "friends":
+		userId, exists := ctx.Get("UserId")
+		id, ok := userId.(uint)
+		if exists == false || !ok {
+			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: You must be logged in to access this resource."})
+			return
+		}
+		query = query.Where(`l.user_id IN (
+			SELECT friend_id FROM friend_ships WHERE user_id = ? AND mutual_friends = true
+			UNION SELECT user_id FROM friend_ships WHERE friend_id = ? AND mutual_friends = true
+			UNION SELECT ?)`, id, id, id)
+	default:
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid scope, expected global or friends"})
+		return
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	rows := []models.LeaderboardRow{}
+	if err := query.
+		Select("u.id, u.display_name, u.nickname, u.avatar, l.wins, l.losses, l.point_difference, l.wins::float / GREATEST(l.wins + l.losses, 1) AS win_rate").
+		Order("l.wins DESC, win_rate DESC, l.point_difference DESC, u.id ASC").
+		Limit(limit).
+		Offset(offset).
+		Scan(&rows).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	for i := range rows {
+		rows[i].Rank = offset + i + 1
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"data": rows, "total": total})
+}
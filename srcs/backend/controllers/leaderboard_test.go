@@ -0,0 +1,89 @@
+package controllers
+
+import (
+	"api/models"
+	"testing"
+	"time"
+)
+
+func TestLeaderboardPeriodStart(t *testing.T) {
+	cest := time.FixedZone("UTC+2", 2*60*60)
+
+	tests := []struct {
+		name   string
+		period string
+		at     time.Time
+		want   time.Time
+	}{
+		{
+			name:   "all time",
+			period: models.LeaderboardAllTime,
+			at:     time.Date(2024, time.March, 13, 15, 4, 5, 0, time.UTC),
+			want:   time.Time{},
+		},
+		{
+			name:   "week from a wednesday",
+			period: models.LeaderboardWeek,
+			at:     time.Date(2024, time.March, 13, 15, 4, 5, 0, time.UTC),
+			want:   time.Date(2024, time.March, 11, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "week from its monday at midnight",
+			period: models.LeaderboardWeek,
+			at:     time.Date(2024, time.March, 11, 0, 0, 0, 0, time.UTC),
+			want:   time.Date(2024, time.March, 11, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "week from a sunday",
+			period: models.LeaderboardWeek,
+			at:     time.Date(2024, time.March, 17, 23, 59, 59, 0, time.UTC),
+			want:   time.Date(2024, time.March, 11, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "week across a new year",
+			period: models.LeaderboardWeek,
+			at:     time.Date(2025, time.January, 1, 8, 0, 0, 0, time.UTC),
+			want:   time.Date(2024, time.December, 30, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "week counted in UTC",
+			period: models.LeaderboardWeek,
+			at:     time.Date(2024, time.March, 11, 1, 0, 0, 0, cest),
+			want:   time.Date(2024, time.March, 4, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "month",
+			period: models.LeaderboardMonth,
+			at:     time.Date(2024, time.February, 29, 23, 59, 59, 0, time.UTC),
+			want:   time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "month from its first instant",
+			period: models.LeaderboardMonth,
+			at:     time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC),
+			want:   time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:   "month counted in UTC",
+			period: models.LeaderboardMonth,
+			at:     time.Date(2024, time.March, 1, 1, 0, 0, 0, cest),
+			want:   time.Date(2024, time.February, 1, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := leaderboardPeriodStart(tt.period, tt.at)
+			if !ok {
+				t.Fatalf("period %q was refused", tt.period)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("period starts %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, ok := leaderboardPeriodStart("year", time.Now()); ok {
+		t.Errorf("an unknown period must be refused")
+	}
+}
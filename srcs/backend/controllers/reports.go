@@ -0,0 +1,148 @@
+package controllers
+
+import (
+	"api/database"
+	"api/models"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func Reports(ctx *gin.RouterGroup) {
+	ctx.GET("", GetReports)
+	ctx.GET("/:id", GetReport)
+	ctx.PUT("/:id", UpdateReport)
+}
+
+func ReportUser(ctx *gin.Context) {
+	userId, exists := ctx.Get("UserId")
+	id, ok := userId.(uint)
+	if exists == false || !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: You must be logged in to access this resource."})
+		return
+	}
+
+	var input models.CreateReportDto
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data"})
+		return
+	}
+	if input.UserID == id {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "You cannot report yourself."})
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, input.UserID).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+
+	// The referenced message or game must be one the two users shared.
+	if input.MessageID != nil {
+		var message models.Message
+		if err := database.DB.First(&message, "id = ? AND ((sender_id = ? AND receiver_id = ?) OR (sender_id = ? AND receiver_id = ?))",
+			*input.MessageID, input.UserID, id, id, input.UserID).Error; err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid message reference"})
+			return
+		}
+	}
+	if input.GameID != nil {
+		var game models.GameHistory
+		if err := database.DB.First(&game, "id = ? AND ((player1_id = ? AND player2_id = ?) OR (player1_id = ? AND player2_id = ?))",
+			*input.GameID, input.UserID, id, id, input.UserID).Error; err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid game reference"})
+			return
+		}
+	}
+
+	report := models.Report{
+		ReporterID: id,
+		ReportedID: input.UserID,
+		Reason:     input.Reason,
+		Details:    input.Details,
+		MessageID:  input.MessageID,
+		GameID:     input.GameID,
+		Status:     models.ReportOpen,
+	}
+	if err := database.DB.Create(&report).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusCreated, gin.H{"success": "Report sent", "id": report.ID})
+}
+
+// GetReports lists the moderation queue, oldest first so nothing starves.
+func GetReports(ctx *gin.Context) {
+	query := database.DB.Order("created_at asc")
+	if status := ctx.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	} else {
+		query = query.Where("status IN ?", []string{models.ReportOpen, models.ReportInReview})
+	}
+	if userId := ctx.Query("userId"); userId != "" {
+		query = query.Where("reported_id = ?", userId)
+	}
+
+	reports := []models.Report{}
+	if err := query.Find(&reports).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, reports)
+}
+
+func GetReport(ctx *gin.Context) {
+	var report models.Report
+	if err := database.DB.First(&report, "id = ?", ctx.Param("id")).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Report not found"})
+		return
+	}
+
+	// Previous reports against the same user help the triage.
+	var previous int64
+	if err := database.DB.Model(&models.Report{}).
+		Where("reported_id = ? AND id <> ?", report.ReportedID, report.ID).
+		Count(&previous).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, gin.H{"report": report, "previousReports": previous})
+}
+
+func UpdateReport(ctx *gin.Context) {
+	userId, exists := ctx.Get("UserId")
+	id, ok := userId.(uint)
+	if exists == false || !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: You must be logged in to access this resource."})
+		return
+	}
+
+	var input models.UpdateReportDto
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data"})
+		return
+	}
+
+	var report models.Report
+	if err := database.DB.First(&report, "id = ?", ctx.Param("id")).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Report not found"})
+		return
+	}
+
+	report.Status = input.Status
+	report.Resolution = input.Resolution
+	report.HandledBy = &id
+	report.ResolvedAt = nil
+	if input.Status == models.ReportResolved || input.Status == models.ReportDismissed {
+		now := time.Now()
+		report.ResolvedAt = &now
+	}
+
+	if err := database.DB.Save(&report).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, report)
+}
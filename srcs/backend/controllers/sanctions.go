@@ -0,0 +1,237 @@
+package controllers
+
+import (
+	"api/database"
+	"api/models"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func Sanctions(ctx *gin.RouterGroup) {
+	ctx.GET("", GetSanctions)
+	ctx.POST("", CreateSanction)
+	ctx.DELETE("/:id", LiftSanction)
+	ctx.GET("/audit", GetSanctionAudit)
+}
+
+// SanctionStatus is what the hub needs to know before relaying a user's
+// events, a nil time means no such sanction is running.
+type SanctionStatus struct {
+	MutedUntil     *time.Time `json:"mutedUntil"`
+	BannedUntil    *time.Time `json:"bannedUntil"`
+	SuspendedUntil *time.Time `json:"suspendedUntil"`
+}
+
+func GetSanctionStatus(userId uint) (*SanctionStatus, error) {
+	now := time.Now()
+	var sanctions []models.Sanction
+	if err := database.DB.
+		Where("user_id = ? AND lifted_at IS NULL AND until > ?", userId, now).
+		Find(&sanctions).Error; err != nil {
+		return nil, err
+	}
+
+	status := foldSanctionStatus(sanctions, now)
+	return &status, nil
+}
+
+// foldSanctionStatus keeps, for each kind, the furthest end among the
+// sanctions still running at now.
+func foldSanctionStatus(sanctions []models.Sanction, now time.Time) SanctionStatus {
+	status := SanctionStatus{}
+	for _, sanction := range sanctions {
+		if sanction.LiftedAt != nil || !sanction.Until.After(now) {
+			continue
+		}
+		until := sanction.Until
+		// Each sanction implies the lighter ones.
+		if status.MutedUntil == nil || until.After(*status.MutedUntil) {
+			status.MutedUntil = &until
+		}
+		if sanction.Kind != models.SanctionMute && (status.BannedUntil == nil || until.After(*status.BannedUntil)) {
+			status.BannedUntil = &until
+		}
+		if sanction.Kind == models.SanctionSuspend && (status.SuspendedUntil == nil || until.After(*status.SuspendedUntil)) {
+			status.SuspendedUntil = &until
+		}
+	}
+	return status
+}
+
+// syncSuspendedUntil mirrors on the user the end of the longest suspension
+// still running, or clears it when none is left.
+func syncSuspendedUntil(tx *gorm.DB, userId uint) (*time.Time, error) {
+	var until sql.NullTime
+	if err := tx.Model(&models.Sanction{}).
+		Select("MAX(until)").
+		Where("user_id = ? AND kind = ? AND lifted_at IS NULL AND until > ?", userId, models.SanctionSuspend, time.Now()).
+		Row().Scan(&until); err != nil {
+		return nil, err
+	}
+
+	var suspendedUntil *time.Time
+	if until.Valid {
+		suspendedUntil = &until.Time
+	}
+	if err := tx.Model(&models.User{}).Where("id = ?", userId).Update("suspended_until", suspendedUntil).Error; err != nil {
+		return nil, err
+	}
+	return suspendedUntil, nil
+}
+
+func auditSanction(tx *gorm.DB, sanction *models.Sanction, actorId uint, action string) error {
+	return tx.Create(&models.SanctionAudit{
+		SanctionID: sanction.ID,
+		ActorID:    actorId,
+		Action:     action,
+		UserID:     sanction.UserID,
+		Kind:       sanction.Kind,
+		Until:      sanction.Until,
+		Reason:     sanction.Reason,
+	}).Error
+}
+
+func GetSanctions(ctx *gin.Context) {
+	query := database.DB.Order("created_at desc")
+	if userId := ctx.Query("userId"); userId != "" {
+		query = query.Where("user_id = ?", userId)
+	}
+	if ctx.Query("active") == "true" {
+		query = query.Where("lifted_at IS NULL AND until > ?", time.Now())
+	}
+
+	sanctions := []models.Sanction{}
+	if err := query.Find(&sanctions).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, sanctions)
+}
+
+func CreateSanction(ctx *gin.Context) {
+	userId, exists := ctx.Get("UserId")
+	id, ok := userId.(uint)
+	if exists == false || !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: You must be logged in to access this resource."})
+		return
+	}
+
+	var input models.CreateSanctionDto
+	if err := ctx.ShouldBindJSON(&input); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid input data"})
+		return
+	}
+	if !input.Until.After(time.Now()) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "The sanction must end in the future"})
+		return
+	}
+	if input.UserID == id {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "You cannot sanction yourself"})
+		return
+	}
+
+	var user models.User
+	if err := database.DB.First(&user, input.UserID).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if !canSanction(ctx, &user) {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Only admins can sanction staff members"})
+		return
+	}
+
+	sanction := models.Sanction{
+		UserID:   input.UserID,
+		Kind:     input.Kind,
+		Reason:   input.Reason,
+		IssuedBy: id,
+		Until:    input.Until,
+	}
+	if err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&sanction).Error; err != nil {
+			return err
+		}
+		return auditSanction(tx, &sanction, id, "applied")
+	}); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusCreated, sanction)
+}
+
+func LiftSanction(ctx *gin.Context) {
+	userId, exists := ctx.Get("UserId")
+	id, ok := userId.(uint)
+	if exists == false || !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: You must be logged in to access this resource."})
+		return
+	}
+
+	var sanction models.Sanction
+	if err := database.DB.First(&sanction, "id = ? AND lifted_at IS NULL", ctx.Param("id")).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Sanction not found"})
+		return
+	}
+	var user models.User
+	if err := database.DB.First(&user, sanction.UserID).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if !canSanction(ctx, &user) {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": "Only admins can lift sanctions on staff members"})
+		return
+	}
+
+	now := time.Now()
+	if err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&sanction).Update("lifted_at", now).Error; err != nil {
+			return err
+		}
+		if sanction.Kind == models.SanctionSuspend {
+			if _, err := syncSuspendedUntil(tx, sanction.UserID); err != nil {
+				return err
+			}
+		}
+		return auditSanction(tx, &sanction, id, "lifted")
+	}); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, sanction)
+}
+
+func GetSanctionAudit(ctx *gin.Context) {
+	query := database.DB.Order("created_at desc")
+	if userId := ctx.Query("userId"); userId != "" {
+		query = query.Where("user_id = ?", userId)
+	}
+
+	audit := []models.SanctionAudit{}
+	if err := query.Find(&audit).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, audit)
+}
+
+// GetUserSanctions is the internal route the hub checks before relaying chat
+// messages or letting someone into a tournament.
+func GetUserSanctions(ctx *gin.Context) {
+	userId, err := strconv.ParseUint(ctx.Param("userId"), 10, 64)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format of user id"})
+		return
+	}
+
+	status, err := GetSanctionStatus(uint(userId))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	ctx.JSON(http.StatusOK, status)
+}
@@ -0,0 +1,94 @@
+package controllers
+
+import (
+	"api/models"
+	"testing"
+	"time"
+)
+
+func TestFoldSanctionStatus(t *testing.T) {
+	now := time.Date(2024, time.March, 10, 12, 0, 0, 0, time.UTC)
+	hour := now.Add(time.Hour)
+	day := now.Add(24 * time.Hour)
+	week := now.Add(7 * 24 * time.Hour)
+	lifted := now.Add(-time.Minute)
+
+	tests := []struct {
+		name          string
+		sanctions     []models.Sanction
+		wantMuted     *time.Time
+		wantBanned    *time.Time
+		wantSuspended *time.Time
+	}{
+		{
+			name: "no sanction",
+		},
+		{
+			name:      "mute",
+			sanctions: []models.Sanction{{Kind: models.SanctionMute, Until: day}},
+			wantMuted: &day,
+		},
+		{
+			name:       "ban implies a mute",
+			sanctions:  []models.Sanction{{Kind: models.SanctionBan, Until: day}},
+			wantMuted:  &day,
+			wantBanned: &day,
+		},
+		{
+			name:          "suspension implies a ban and a mute",
+			sanctions:     []models.Sanction{{Kind: models.SanctionSuspend, Until: hour}},
+			wantMuted:     &hour,
+			wantBanned:    &hour,
+			wantSuspended: &hour,
+		},
+		{
+			name: "furthest end wins for each kind",
+			sanctions: []models.Sanction{
+				{Kind: models.SanctionMute, Until: week},
+				{Kind: models.SanctionSuspend, Until: hour},
+				{Kind: models.SanctionBan, Until: day},
+			},
+			wantMuted:     &week,
+			wantBanned:    &day,
+			wantSuspended: &hour,
+		},
+		{
+			name: "expired sanctions are ignored",
+			sanctions: []models.Sanction{
+				{Kind: models.SanctionBan, Until: now.Add(-time.Hour)},
+				{Kind: models.SanctionSuspend, Until: now},
+				{Kind: models.SanctionMute, Until: hour},
+			},
+			wantMuted: &hour,
+		},
+		{
+			name: "lifted sanctions are ignored",
+			sanctions: []models.Sanction{
+				{Kind: models.SanctionSuspend, Until: week, LiftedAt: &lifted},
+				{Kind: models.SanctionBan, Until: day},
+			},
+			wantMuted:  &day,
+			wantBanned: &day,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status := foldSanctionStatus(tt.sanctions, now)
+			assertUntil(t, "muted", status.MutedUntil, tt.wantMuted)
+			assertUntil(t, "banned", status.BannedUntil, tt.wantBanned)
+			assertUntil(t, "suspended", status.SuspendedUntil, tt.wantSuspended)
+		})
+	}
+}
+
+func assertUntil(t *testing.T, what string, got *time.Time, want *time.Time) {
+	t.Helper()
+	switch {
+	case got == nil && want == nil:
+	case got == nil || want == nil:
+		t.Errorf("%s until %v, want %v", what, got, want)
+	case !got.Equal(*want):
+		t.Errorf("%s until %v, want %v", what, *got, *want)
+	}
+}
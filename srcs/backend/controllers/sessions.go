@@ -0,0 +1,142 @@
+package controllers
+
+import (
+	"api/database"
+	"api/models"
+	"api/utils"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func Sessions(ctx *gin.RouterGroup) {
+	ctx.GET("", GetSessions)
+	ctx.DELETE("", RevokeOtherSessions)
+	ctx.DELETE("/:sessionId", RevokeOneSession)
+}
+
+type SessionResponse struct {
+	models.Session
+	Current bool `json:"current"`
+}
+
+func CreateSession(ctx *gin.Context, tx *gorm.DB, userId uint) (*models.Session, error) {
+	id, err := utils.GenerateRandomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	session := models.Session{
+		ID:         id,
+		UserID:     userId,
+		UserAgent:  ctx.Request.UserAgent(),
+		IP:         ctx.ClientIP(),
+		LastSeenAt: time.Now(),
+	}
+	if err := tx.Create(&session).Error; err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// RevokeSession ends a login: the session is flagged and all of its tokens
+// are revoked.
+func RevokeSession(tx *gorm.DB, sessionId string) error {
+	if err := tx.Model(&models.Session{}).
+		Where("id = ? AND revoked_at IS NULL", sessionId).
+		Update("revoked_at", time.Now()).Error; err != nil {
+		return err
+	}
+	return revokeRefreshTokens(tx, "session_id = ?", sessionId)
+}
+
+// RevokeUserSessions ends every login of the user except the given one, an
+// empty id revokes them all.
+func RevokeUserSessions(tx *gorm.DB, userId uint, exceptSessionId string) error {
+	var sessions []models.Session
+	if err := tx.Where("user_id = ? AND revoked_at IS NULL AND id <> ?", userId, exceptSessionId).Find(&sessions).Error; err != nil {
+		return err
+	}
+
+	for _, session := range sessions {
+		if err := RevokeSession(tx, session.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func GetSessions(ctx *gin.Context) {
+	userId, exists := ctx.Get("UserId")
+	id, ok := userId.(uint)
+	if exists == false || !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: You must be logged in to access this resource."})
+		return
+	}
+	currentId := ctx.GetString("SessionId")
+
+	var sessions []models.Session
+	if err := database.DB.
+		Where("user_id = ? AND revoked_at IS NULL", id).
+		Order("last_seen_at desc").
+		Find(&sessions).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	response := []SessionResponse{}
+	for _, session := range sessions {
+		response = append(response, SessionResponse{
+			Session: session,
+			Current: session.ID == currentId,
+		})
+	}
+	ctx.JSON(http.StatusOK, response)
+}
+
+func RevokeOneSession(ctx *gin.Context) {
+	userId, exists := ctx.Get("UserId")
+	id, ok := userId.(uint)
+	if exists == false || !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: You must be logged in to access this resource."})
+		return
+	}
+
+	var session models.Session
+	if err := database.DB.First(&session, "id = ? AND user_id = ? AND revoked_at IS NULL", ctx.Param("sessionId"), id).Error; err != nil {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return
+	}
+
+	if err := database.DB.Transaction(func(tx *gorm.DB) error {
+		return RevokeSession(tx, session.ID)
+	}); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Could not revoke session"})
+		return
+	}
+
+	if session.ID == ctx.GetString("SessionId") {
+		ClearTokenCookies(ctx)
+	}
+	ctx.JSON(http.StatusOK, gin.H{"success": "Session revoked"})
+}
+
+func RevokeOtherSessions(ctx *gin.Context) {
+	userId, exists := ctx.Get("UserId")
+	id, ok := userId.(uint)
+	if exists == false || !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: You must be logged in to access this resource."})
+		return
+	}
+
+	if err := database.DB.Transaction(func(tx *gorm.DB) error {
+		return RevokeUserSessions(tx, id, ctx.GetString("SessionId"))
+	}); err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Could not revoke sessions"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"success": "Other sessions revoked"})
+}
@@ -0,0 +1,146 @@
+package controllers
+
+import (
+	"api/database"
+	"api/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type ModeStats struct {
+	Games int64 `json:"games"`
+	Wins  int64 `json:"wins"`
+}
+
+type UserStats struct {
+	Games           int64     `json:"games"`
+	Wins            int64     `json:"wins"`
+	Losses          int64     `json:"losses"`
+	WinRate         float64   `json:"winRate"`
+	CurrentStreak   int       `json:"currentStreak"`
+	BestStreak      int       `json:"bestStreak"`
+	PointsScored    int64     `json:"pointsScored"`
+	PointsConceded  int64     `json:"pointsConceded"`
+	AverageScore    float64   `json:"averageScore"`
+	AverageDuration float64   `json:"averageDuration"`
+	Classic         ModeStats `json:"classic"`
+	Special         ModeStats `json:"special"`
+}
+
+// computeUserStats folds the games, oldest first, from the user's point of
+// view. Games saved before durations were recorded are left out of the
+// average duration.
+func computeUserStats(userId uint64, games []models.GameHistory) UserStats {
+	stats := UserStats{}
+	streak := 0
+	var timedGames int64
+	var totalDuration float64
+
+	for _, game := range games {
+		scored, conceded := game.Score1, game.Score2
+		if game.Player2ID == userId {
+			scored, conceded = game.Score2, game.Score1
+		}
+		won := game.WinnerID == userId
+
+		stats.Games++
+		stats.PointsScored += int64(scored)
+		stats.PointsConceded += int64(conceded)
+		mode := &stats.Classic
+		if game.IsGameMode {
+			mode = &stats.Special
+		}
+		mode.Games++
+
+		if won {
+			stats.Wins++
+			mode.Wins++
+			streak++
+			stats.BestStreak = max(stats.BestStreak, streak)
+		} else {
+			stats.Losses++
+			streak = 0
+		}
+
+		if game.Duration > 0 {
+			timedGames++
+			totalDuration += game.Duration
+		}
+	}
+
+	stats.CurrentStreak = streak
+	if stats.Games > 0 {
+		stats.WinRate = float64(stats.Wins) / float64(stats.Games)
+		stats.AverageScore = float64(stats.PointsScored) / float64(stats.Games)
+	}
+	if timedGames > 0 {
+		stats.AverageDuration = totalDuration / float64(timedGames)
+	}
+	return stats
+}
+
+func findUserByNickname(ctx *gin.Context, nickname string) (*models.User, bool) {
+	var user models.User
+	if err := database.DB.Where("nickname = ?", nickname).First(&user).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			ctx.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return nil, false
+		}
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return &user, true
+}
+
+func GetUserStats(ctx *gin.Context) {
+	user, ok := findUserByNickname(ctx, ctx.Param("nickname"))
+	if !ok {
+		return
+	}
+
+	var games []models.GameHistory
+	if err := database.DB.
+		Where("player1_id = ? OR player2_id = ?", user.ID, user.ID).
+		Order("created_at asc").
+		Find(&games).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"nickname": user.Nickname,
+		"stats":    computeUserStats(uint64(user.ID), games),
+	})
+}
+
+func GetHeadToHeadStats(ctx *gin.Context) {
+	user, ok := findUserByNickname(ctx, ctx.Param("nickname"))
+	if !ok {
+		return
+	}
+	opponent, ok := findUserByNickname(ctx, ctx.Param("other"))
+	if !ok {
+		return
+	}
+	if user.ID == opponent.ID {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "A head-to-head needs two different users"})
+		return
+	}
+
+	var games []models.GameHistory
+	if err := database.DB.
+		Where("(player1_id = ? AND player2_id = ?) OR (player1_id = ? AND player2_id = ?)", user.ID, opponent.ID, opponent.ID, user.ID).
+		Order("created_at asc").
+		Find(&games).Error; err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"nickname": user.Nickname,
+		"opponent": opponent.Nickname,
+		"stats":    computeUserStats(uint64(user.ID), games),
+	})
+}
@@ -0,0 +1,90 @@
+package controllers
+
+import (
+	"api/models"
+	"testing"
+)
+
+func TestComputeUserStats(t *testing.T) {
+	const me, alice, bob = 1, 2, 3
+
+	tests := []struct {
+		name  string
+		games []models.GameHistory
+		want  UserStats
+	}{
+		{
+			name: "no game",
+			want: UserStats{},
+		},
+		{
+			name: "streaks, legacy durations and modes",
+			games: []models.GameHistory{
+				// Saved before durations were recorded.
+				{Player1ID: me, Player2ID: alice, WinnerID: me, Score1: 11, Score2: 5},
+				{Player1ID: alice, Player2ID: me, WinnerID: me, Score1: 3, Score2: 11, IsGameMode: true, Duration: 120},
+				{Player1ID: me, Player2ID: bob, WinnerID: me, Score1: 11, Score2: 9, Duration: 60},
+				{Player1ID: bob, Player2ID: me, WinnerID: bob, Score1: 11, Score2: 2, IsGameMode: true},
+				{Player1ID: me, Player2ID: alice, WinnerID: me, Score1: 11, Score2: 0, Duration: 90},
+			},
+			want: UserStats{
+				Games:           5,
+				Wins:            4,
+				Losses:          1,
+				WinRate:         0.8,
+				CurrentStreak:   1,
+				BestStreak:      3,
+				PointsScored:    46,
+				PointsConceded:  28,
+				AverageScore:    9.2,
+				AverageDuration: 90,
+				Classic:         ModeStats{Games: 3, Wins: 3},
+				Special:         ModeStats{Games: 2, Wins: 1},
+			},
+		},
+		{
+			name: "only legacy durations",
+			games: []models.GameHistory{
+				{Player1ID: me, Player2ID: alice, WinnerID: alice, Score1: 4, Score2: 11},
+				{Player1ID: alice, Player2ID: me, WinnerID: alice, Score1: 11, Score2: 7},
+			},
+			want: UserStats{
+				Games:          2,
+				Losses:         2,
+				PointsScored:   11,
+				PointsConceded: 22,
+				AverageScore:   5.5,
+				Classic:        ModeStats{Games: 2},
+			},
+		},
+		{
+			name: "current streak at its best",
+			games: []models.GameHistory{
+				{Player1ID: me, Player2ID: bob, WinnerID: bob, Score1: 9, Score2: 11, IsGameMode: true, Duration: 30},
+				{Player1ID: me, Player2ID: bob, WinnerID: me, Score1: 11, Score2: 9, IsGameMode: true, Duration: 40},
+				{Player1ID: bob, Player2ID: me, WinnerID: me, Score1: 10, Score2: 11, IsGameMode: true, Duration: 50},
+			},
+			want: UserStats{
+				Games:           3,
+				Wins:            2,
+				Losses:          1,
+				WinRate:         2.0 / 3.0,
+				CurrentStreak:   2,
+				BestStreak:      2,
+				PointsScored:    31,
+				PointsConceded:  30,
+				AverageScore:    31.0 / 3.0,
+				AverageDuration: 40,
+				Special:         ModeStats{Games: 3, Wins: 2},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := computeUserStats(me, tt.games); got != tt.want {
+				t.Errorf("got %+v\nwant %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,172 @@
+package controllers
+
+import (
+	"api/database"
+	"api/models"
+	"api/utils"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+var ErrRefreshTokenReused = errors.New("refresh token reused")
+
+type IssuedTokens struct {
+	AccessToken  string
+	RefreshToken string
+}
+
+// IssueTokens signs a new access token and a refresh token belonging to the
+// given session. An empty session id starts a new session for the requesting
+// device. Cookies are only set by SetTokenCookies, once the caller's
+// transaction committed.
+func IssueTokens(ctx *gin.Context, tx *gorm.DB, userId uint, sessionId string) (*IssuedTokens, error) {
+	if sessionId == "" {
+		session, err := CreateSession(ctx, tx, userId)
+		if err != nil {
+			return nil, err
+		}
+		sessionId = session.ID
+	}
+
+	var user models.User
+	if err := tx.Select("id", "role").First(&user, userId).Error; err != nil {
+		return nil, err
+	}
+
+	accessToken, claims, err := utils.CreateToken(userId, user.Role, sessionId)
+	if err != nil {
+		return nil, err
+	}
+
+	refreshToken, err := utils.GenerateRandomToken()
+	if err != nil {
+		return nil, err
+	}
+
+	row := models.RefreshToken{
+		UserID:    userId,
+		SessionID: sessionId,
+		TokenHash: utils.HashToken(refreshToken),
+		AccessJTI: claims.JTI,
+		ExpiresAt: time.Now().Add(utils.RefreshTokenTTL),
+	}
+	if err := tx.Create(&row).Error; err != nil {
+		return nil, err
+	}
+	return &IssuedTokens{AccessToken: accessToken, RefreshToken: refreshToken}, nil
+}
+
+func SetTokenCookies(ctx *gin.Context, tokens *IssuedTokens) {
+	ctx.SetCookie("access_token", tokens.AccessToken, int(utils.AccessTokenTTL.Seconds()), "/", "", false, true)
+	ctx.SetCookie("refresh_token", tokens.RefreshToken, int(utils.RefreshTokenTTL.Seconds()), "/", "", false, true)
+}
+
+func ClearTokenCookies(ctx *gin.Context) {
+	ctx.SetCookie("access_token", "", -1, "/", "", false, true)
+	ctx.SetCookie("refresh_token", "", -1, "/", "", false, true)
+}
+
+func RevokeAccessToken(tx *gorm.DB, jti string, expiresAt time.Time) error {
+	if jti == "" || expiresAt.Before(time.Now()) {
+		return nil
+	}
+	if err := tx.Where("expires_at < ?", time.Now()).Delete(&models.RevokedToken{}).Error; err != nil {
+		return err
+	}
+	return tx.Save(&models.RevokedToken{JTI: jti, ExpiresAt: expiresAt}).Error
+}
+
+// revokeRefreshTokens revokes the still active refresh tokens matching the
+// query along with the access token issued next to each of them.
+func revokeRefreshTokens(tx *gorm.DB, query string, args ...interface{}) error {
+	var tokens []models.RefreshToken
+	if err := tx.Where("revoked_at IS NULL").Where(query, args...).Find(&tokens).Error; err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, token := range tokens {
+		if err := RevokeAccessToken(tx, token.AccessJTI, token.CreatedAt.Add(utils.AccessTokenTTL)); err != nil {
+			return err
+		}
+		if err := tx.Model(&token).Update("revoked_at", now).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rotateRefreshToken consumes the presented refresh token and issues a new
+// pair in the same session.
+func rotateRefreshToken(ctx *gin.Context, tx *gorm.DB, refreshToken string) (*IssuedTokens, error) {
+	var current models.RefreshToken
+	if err := tx.Where("token_hash = ?", utils.HashToken(refreshToken)).First(&current).Error; err != nil {
+		return nil, err
+	}
+
+	if current.ExpiresAt.Before(time.Now()) {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	var session models.Session
+	if err := tx.Where("id = ? AND revoked_at IS NULL", current.SessionID).First(&session).Error; err != nil {
+		return nil, err
+	}
+
+	result := tx.Model(&models.RefreshToken{}).
+		Where("id = ? AND revoked_at IS NULL", current.ID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, ErrRefreshTokenReused
+	}
+
+	if err := RevokeAccessToken(tx, current.AccessJTI, current.CreatedAt.Add(utils.AccessTokenTTL)); err != nil {
+		return nil, err
+	}
+	return IssueTokens(ctx, tx, current.UserID, current.SessionID)
+}
+
+func RefreshTokens(ctx *gin.Context) {
+	refreshToken, err := ctx.Cookie("refresh_token")
+	if err != nil || refreshToken == "" {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Missing refresh token"})
+		return
+	}
+
+	var tokens *IssuedTokens
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		tokens, err = rotateRefreshToken(ctx, tx, refreshToken)
+		return err
+	})
+
+	if errors.Is(err, ErrRefreshTokenReused) {
+		// Someone replayed an already rotated token: the session is compromised.
+		var reused models.RefreshToken
+		if database.DB.Where("token_hash = ?", utils.HashToken(refreshToken)).First(&reused).Error == nil {
+			RevokeSession(database.DB, reused.SessionID)
+		}
+		ClearTokenCookies(ctx)
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token already used, please sign in again"})
+		return
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		ClearTokenCookies(ctx)
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired refresh token"})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "An error occurred while refreshing the token. Please try again."})
+		return
+	}
+
+	SetTokenCookies(ctx, tokens)
+	ctx.JSON(http.StatusOK, gin.H{"success": "Token refreshed"})
+}
@@ -0,0 +1,141 @@
+package controllers
+
+import (
+	"api/models"
+	"api/utils"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func newTokenContext(refreshToken string) (*gin.Context, *httptest.ResponseRecorder) {
+	recorder := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(recorder)
+	ctx.Request = httptest.NewRequest(http.MethodPost, "/api/auth/refresh", nil)
+	if refreshToken != "" {
+		ctx.Request.AddCookie(&http.Cookie{Name: "refresh_token", Value: refreshToken})
+	}
+	return ctx, recorder
+}
+
+func issueTestTokens(t *testing.T, tx *gorm.DB, nickname string) (models.User, *IssuedTokens) {
+	t.Helper()
+	t.Setenv("JWT_SIGNATURE", "test-signature")
+	user := createTestUser(t, tx, nickname)
+	ctx, _ := newTokenContext("")
+	tokens, err := IssueTokens(ctx, tx, user.ID, "")
+	if err != nil {
+		t.Fatalf("issuing tokens: %v", err)
+	}
+	return user, tokens
+}
+
+func findRefreshToken(t *testing.T, tx *gorm.DB, refreshToken string) models.RefreshToken {
+	t.Helper()
+	var row models.RefreshToken
+	if err := tx.Where("token_hash = ?", utils.HashToken(refreshToken)).First(&row).Error; err != nil {
+		t.Fatalf("finding refresh token: %v", err)
+	}
+	return row
+}
+
+func TestRotateRefreshToken(t *testing.T) {
+	withTestDB(t, func(tx *gorm.DB) {
+		user, tokens := issueTestTokens(t, tx, "rotator")
+		ctx, _ := newTokenContext(tokens.RefreshToken)
+
+		rotated, err := rotateRefreshToken(ctx, tx, tokens.RefreshToken)
+		if err != nil {
+			t.Fatalf("rotating: %v", err)
+		}
+		if rotated.RefreshToken == tokens.RefreshToken || rotated.AccessToken == tokens.AccessToken {
+			t.Fatalf("rotation must issue a new pair")
+		}
+
+		previous := findRefreshToken(t, tx, tokens.RefreshToken)
+		next := findRefreshToken(t, tx, rotated.RefreshToken)
+		if previous.RevokedAt == nil {
+			t.Errorf("the rotated refresh token is still active")
+		}
+		if next.RevokedAt != nil || next.SessionID != previous.SessionID || next.UserID != user.ID {
+			t.Errorf("the new refresh token must be active in the same session, got %+v", next)
+		}
+		var revoked int64
+		tx.Model(&models.RevokedToken{}).Where("jti = ?", previous.AccessJTI).Count(&revoked)
+		if revoked != 1 {
+			t.Errorf("the access token issued with the rotated refresh token is not revoked")
+		}
+
+		if _, err := rotateRefreshToken(ctx, tx, rotated.RefreshToken); err != nil {
+			t.Errorf("rotating the new refresh token: %v", err)
+		}
+	})
+}
+
+func TestRotateExpiredRefreshToken(t *testing.T) {
+	withTestDB(t, func(tx *gorm.DB) {
+		_, tokens := issueTestTokens(t, tx, "expired")
+		row := findRefreshToken(t, tx, tokens.RefreshToken)
+		tx.Model(&row).Update("expires_at", time.Now().Add(-time.Minute))
+
+		ctx, _ := newTokenContext(tokens.RefreshToken)
+		if _, err := rotateRefreshToken(ctx, tx, tokens.RefreshToken); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Errorf("rotating an expired refresh token returned %v", err)
+		}
+		if _, err := rotateRefreshToken(ctx, tx, "unknown"); !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Errorf("rotating an unknown refresh token returned %v", err)
+		}
+	})
+}
+
+func TestRefreshTokenReuseRevokesSession(t *testing.T) {
+	withTestDB(t, func(tx *gorm.DB) {
+		_, tokens := issueTestTokens(t, tx, "replayed")
+
+		ctx, recorder := newTokenContext(tokens.RefreshToken)
+		RefreshTokens(ctx)
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("first refresh answered %d: %s", recorder.Code, recorder.Body)
+		}
+		var rotated string
+		for _, cookie := range recorder.Result().Cookies() {
+			if cookie.Name == "refresh_token" {
+				rotated = cookie.Value
+			}
+		}
+		if rotated == "" || rotated == tokens.RefreshToken {
+			t.Fatalf("first refresh did not set a new refresh token")
+		}
+
+		// The stolen, already rotated token is presented again.
+		ctx, recorder = newTokenContext(tokens.RefreshToken)
+		RefreshTokens(ctx)
+		if recorder.Code != http.StatusUnauthorized {
+			t.Fatalf("replay answered %d, want %d", recorder.Code, http.StatusUnauthorized)
+		}
+
+		row := findRefreshToken(t, tx, tokens.RefreshToken)
+		var session models.Session
+		if err := tx.First(&session, "id = ?", row.SessionID).Error; err != nil {
+			t.Fatal(err)
+		}
+		if session.RevokedAt == nil {
+			t.Errorf("the session survived the replay")
+		}
+		if next := findRefreshToken(t, tx, rotated); next.RevokedAt == nil {
+			t.Errorf("the legitimate refresh token survived the replay")
+		}
+
+		// The legitimate holder has to sign in again too.
+		ctx, recorder = newTokenContext(rotated)
+		RefreshTokens(ctx)
+		if recorder.Code != http.StatusUnauthorized {
+			t.Errorf("refreshing in a revoked session answered %d, want %d", recorder.Code, http.StatusUnauthorized)
+		}
+	})
+}
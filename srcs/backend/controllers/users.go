@@ -15,6 +15,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
 func Users(ctx *gin.RouterGroup) {
@@ -30,8 +31,12 @@ func Users(ctx *gin.RouterGroup) {
 
 	ctx.PUT("/update-profile", UpdateProfile)
 	ctx.PUT("/change-password", ChangePassword)
+	ctx.POST("/report", ReportUser)
+	ctx.GET("/achievements", GetAchievements)
 
 	FriendShip(ctx.Group("/friendships"))
+	Sessions(ctx.Group("/sessions"))
+	Blocks(ctx.Group("/blocks"))
 	ctx.DELETE("/delete-account", DeleteAccount)
 }
 
@@ -54,7 +59,7 @@ func GetUser(ctx *gin.Context) {
 	}
 
 	var user models.UserResponse
-	result := database.DB.Raw("SELECT id, display_name, nickname, avatar FROM users WHERE id = ?", id).Scan(&user)
+	result := database.DB.Raw("SELECT id, display_name, nickname, avatar, role FROM users WHERE id = ?", id).Scan(&user)
 
 	if result.Error != nil {
 		ctx.JSON(http.StatusNotFound, gin.H{"error": result.Error.Error()})
@@ -77,7 +82,7 @@ func GetUserByID(ctx *gin.Context) {
 	}
 
 	var user models.UserResponse
-	result := database.DB.Raw("SELECT id, display_name, nickname, avatar FROM users WHERE id = ?", id).Scan(&user)
+	result := database.DB.Raw("SELECT id, display_name, nickname, avatar, role, rating, rating_deviation FROM users WHERE id = ?", id).Scan(&user)
 
 	if result.Error != nil {
 		ctx.JSON(http.StatusNotFound, gin.H{"error": result.Error.Error()})
@@ -100,7 +105,7 @@ func GetUserByNickname(ctx *gin.Context) {
 	}
 
 	var user models.UserResponse
-	result := database.DB.Raw("SELECT id, display_name, nickname, avatar FROM users WHERE nickname = ?", nickname).Scan(&user)
+	result := database.DB.Raw("SELECT id, display_name, nickname, avatar, role, rating, rating_deviation FROM users WHERE nickname = ?", nickname).Scan(&user)
 
 	if result.Error != nil {
 		ctx.JSON(http.StatusNotFound, gin.H{"error": result.Error.Error()})
@@ -253,11 +258,18 @@ func DeleteAccount(ctx *gin.Context) {
 		return
 	}
 
-	if err := database.DB.Delete(&user).Error; err != nil {
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := RevokeUserSessions(tx, user.ID, ""); err != nil {
+			return err
+		}
+		return tx.Delete(&user).Error
+	})
+	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Could not delete user"})
 		return
 	}
 
+	ClearTokenCookies(ctx)
 	ctx.JSON(http.StatusOK, gin.H{"message": "Account deleted successfully"})
 }
 
@@ -303,11 +315,24 @@ func ChangePassword(ctx *gin.Context) {
 		return
 	}
 
+	// Every existing login is revoked, the caller gets a fresh one.
 	user.Password = string(hashedPassword)
-	if err := database.DB.Save(&user).Error; err != nil {
+	var tokens *IssuedTokens
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&user).Error; err != nil {
+			return err
+		}
+		if err := RevokeUserSessions(tx, user.ID, ""); err != nil {
+			return err
+		}
+		tokens, err = IssueTokens(ctx, tx, user.ID, "")
+		return err
+	})
+	if err != nil {
 		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "Could not update password"})
 		return
 	}
+	SetTokenCookies(ctx, tokens)
 
 	ctx.JSON(http.StatusOK, gin.H{"success": "Password updated successfully"})
 }
@@ -21,6 +21,7 @@ func New() {
 	CreateMockUsers()
 	CreateMockConversation()
 	CreateMockGames()
+	CreateAdmin()
 }
 
 func initDB() *gorm.DB {
@@ -41,11 +42,57 @@ func initDB() *gorm.DB {
 		log.Fatalln(err)
 	}
 
-	database.AutoMigrate(&models.User{}, &models.TwoFactorAuth{}, &models.FriendShip{}, &models.Message{}, &models.GameHistory{})
+	database.AutoMigrate(&models.User{}, &models.TwoFactorAuth{}, &models.FriendShip{}, &models.Message{}, &models.GameHistory{}, &models.Session{}, &models.RefreshToken{}, &models.RevokedToken{}, &models.Channel{}, &models.ChannelMember{}, &models.ChannelMessage{}, &models.ChannelSanction{}, &models.Sanction{}, &models.SanctionAudit{}, &models.Block{}, &models.Report{}, &models.LeaderboardEntry{}, &models.UserAchievement{}, &models.GameReplay{})
 
 	return database
 }
 
+// CreateAdmin gives the admin role to the ADMIN_NICKNAME account, which is
+// created with ADMIN_PASSWORD when it does not exist yet. An existing account
+// is only promoted when its password is ADMIN_PASSWORD, so registering the
+// nickname first is not enough to become admin. It is how the first admin of
+// an instance is made, the next ones are promoted from the admin panel.
+func CreateAdmin() {
+	nickname := strings.ToLower(os.Getenv("ADMIN_NICKNAME"))
+	if nickname == "" {
+		return
+	}
+	password := os.Getenv("ADMIN_PASSWORD")
+	if password == "" {
+		log.Printf("ADMIN_NICKNAME is set but ADMIN_PASSWORD is not")
+		return
+	}
+
+	var user models.User
+	if err := DB.First(&user, "nickname = ?", nickname).Error; err == nil {
+		if user.Role == models.RoleAdmin {
+			return
+		}
+		if bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)) != nil {
+			log.Printf("Refusing to promote %s to admin: the password does not match ADMIN_PASSWORD", nickname)
+			return
+		}
+		if err := DB.Model(&user).Update("role", models.RoleAdmin).Error; err != nil {
+			log.Printf("Failed to promote %s to admin: %v", nickname, err)
+			return
+		}
+		log.Printf("Promoted %s to admin", nickname)
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), 10)
+	if err != nil {
+		log.Printf("Failed to hash the admin password: %v", err)
+		return
+	}
+	user = models.User{Nickname: nickname, DisplayName: nickname, Password: string(hashedPassword), Role: models.RoleAdmin}
+	if err := DB.Create(&user).Error; err != nil {
+		log.Printf("Failed to create admin %s: %v", nickname, err)
+		return
+	}
+	log.Printf("Created admin: %s", nickname)
+}
+
 func CreateMockUsers() {
 	users := []models.User{
 		{Nickname: "Hichame", DisplayName: "hichame", Password: "hichame42LH"},
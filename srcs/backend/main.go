@@ -4,7 +4,9 @@ import (
 	"api/controllers"
 	"api/database"
 	"api/middleware"
+	"api/models"
 	"api/prometheus"
+	"log"
 	"time"
 
 	"github.com/gin-contrib/cors"
@@ -15,6 +17,9 @@ import (
 func main() {
 	router := gin.Default()
 	database.New()
+	if err := controllers.BackfillLeaderboard(); err != nil {
+		log.Printf("Failed to backfill the leaderboard: %v", err)
+	}
 
 	// Configuration CORS
 	config := cors.Config{
@@ -36,14 +41,29 @@ func main() {
 
 	// Routes
 	router.Static("/users/avatar", "./avatars")
-	router.POST("/api/game-history", controllers.SaveGameHistory)
 	router.GET("/api/game-history/:nickname", controllers.GetUserGameHistory)
-	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+	router.GET("/api/game-replay/:id", controllers.GetGameReplay)
+	router.GET("/api/leaderboard", controllers.GetLeaderboard)
+	router.GET("/api/stats/:nickname", controllers.GetUserStats)
+	router.GET("/api/stats/:nickname/vs/:other", controllers.GetHeadToHeadStats)
+	router.GET("/metrics", middleware.MetricsGuard(), gin.WrapH(promhttp.Handler()))
 
 	users := router.Group("/users")
 	auth := router.Group("/auth")
 	conversation := router.Group("/conversation")
+	channels := router.Group("/channels")
+	internal := router.Group("/internal")
+	admin := router.Group("/admin")
+	conversation.Use(middleware.AuthGuard())
 	controllers.Conversation(conversation)
+	channels.Use(middleware.AuthGuard())
+	controllers.Channels(channels)
+	admin.Use(middleware.AuthGuard(), middleware.RequireRole(models.RoleModerator, models.RoleAdmin))
+	controllers.Sanctions(admin.Group("/sanctions"))
+	controllers.Reports(admin.Group("/reports"))
+	controllers.AdminUsers(admin.Group("/users"))
+	internal.Use(middleware.ServiceGuard())
+	controllers.Internal(internal)
 	users.Use(middleware.AuthGuard())
 	controllers.Auth(auth)
 	controllers.Users(users)
@@ -1,8 +1,16 @@
 package middleware
 
 import (
+	"api/database"
+	"api/models"
 	"api/utils"
+	"crypto/subtle"
+	"fmt"
 	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -21,13 +29,48 @@ func Token() gin.HandlerFunc {
 			return
 		}
 
+		var revoked int64
+		if err := database.DB.Model(&models.RevokedToken{}).Where("jti = ?", user.JTI).Count(&revoked).Error; err != nil || revoked > 0 {
+			ctx.Next()
+			return
+		}
+
+		var session models.Session
+		if err := database.DB.Where("id = ? AND user_id = ? AND revoked_at IS NULL", user.SessionID, user.ID).First(&session).Error; err != nil {
+			ctx.Next()
+			return
+		}
+		var account models.User
+		if err := database.DB.Select("id", "suspended_until").First(&account, user.ID).Error; err != nil {
+			ctx.Next()
+			return
+		}
+		if account.IsSuspended() {
+			ctx.Set("SuspendedUntil", *account.SuspendedUntil)
+			ctx.Next()
+			return
+		}
+
+		if time.Since(session.LastSeenAt) > time.Minute {
+			database.DB.Model(&session).Update("last_seen_at", time.Now())
+		}
+
 		ctx.Set("UserId", user.ID)
+		ctx.Set("Role", user.Role)
+		ctx.Set("SessionId", session.ID)
+		ctx.Set("TokenId", user.JTI)
+		ctx.Set("TokenExpiresAt", user.ExpiresAt)
 		ctx.Next()
 	}
 }
 
 func AuthGuard() gin.HandlerFunc {
 	return func(ctx *gin.Context) {
+		if until, suspended := ctx.Get("SuspendedUntil"); suspended {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": fmt.Sprintf("Your account is suspended until %s.", until.(time.Time).Format(time.RFC3339))})
+			ctx.Abort()
+			return
+		}
 		id, exists := ctx.Get("UserId")
 		if !exists || id == nil {
 			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: You must be logged in to access this resource."})
@@ -37,3 +80,60 @@ func AuthGuard() gin.HandlerFunc {
 		ctx.Next()
 	}
 }
+
+// RequireRole lets the request through only if the role carried by the
+// access token is one of the given ones, it runs after AuthGuard.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if !slices.Contains(roles, ctx.GetString("Role")) {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "Forbidden: you are not allowed to access this resource."})
+			ctx.Abort()
+			return
+		}
+		ctx.Next()
+	}
+}
+
+// MetricsGuard lets Prometheus scrape with the METRICS_TOKEN bearer, anyone
+// else must be an admin.
+func MetricsGuard() gin.HandlerFunc {
+	requireAdmin := RequireRole(models.RoleAdmin)
+	return func(ctx *gin.Context) {
+		expected := os.Getenv("METRICS_TOKEN")
+		token, found := strings.CutPrefix(ctx.GetHeader("Authorization"), "Bearer ")
+		if found && expected != "" && subtle.ConstantTimeCompare([]byte(token), []byte(expected)) == 1 {
+			ctx.Next()
+			return
+		}
+		requireAdmin(ctx)
+	}
+}
+
+// ServiceGuard protects the internal routes called by our other services: it
+// only accepts a service token and refuses any request carrying user cookies.
+func ServiceGuard() gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		if _, err := ctx.Cookie("access_token"); err == nil {
+			ctx.JSON(http.StatusForbidden, gin.H{"error": "Forbidden: internal routes do not accept user credentials."})
+			ctx.Abort()
+			return
+		}
+
+		token, found := strings.CutPrefix(ctx.GetHeader("Authorization"), "Bearer ")
+		if !found {
+			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: missing service token."})
+			ctx.Abort()
+			return
+		}
+
+		service, err := utils.DecryptServiceToken(token)
+		if err != nil {
+			ctx.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized: invalid service token."})
+			ctx.Abort()
+			return
+		}
+
+		ctx.Set("Service", service.Service)
+		ctx.Next()
+	}
+}
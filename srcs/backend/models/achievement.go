@@ -0,0 +1,39 @@
+package models
+
+import "time"
+
+const (
+	AchievementFirstWin           = "first_win"
+	AchievementWinStreak          = "win_streak_10"
+	AchievementTournamentChampion = "tournament_champion"
+	AchievementShutout            = "shutout"
+	AchievementBoostMaster        = "boost_master"
+	AchievementVeteran            = "veteran"
+)
+
+type Achievement struct {
+	Code        string `json:"code"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// Achievements is the catalog, the backend decides when each one unlocks.
+var Achievements = []Achievement{
+	{Code: AchievementFirstWin, Name: "First blood", Description: "Win your first game"},
+	{Code: AchievementWinStreak, Name: "Unstoppable", Description: "Win 10 games in a row"},
+	{Code: AchievementTournamentChampion, Name: "Champion", Description: "Win a tournament"},
+	{Code: AchievementShutout, Name: "Clean sheet", Description: "Win a game without conceding a point"},
+	{Code: AchievementBoostMaster, Name: "Boost master", Description: "Land 3 boosted hits in a single game"},
+	{Code: AchievementVeteran, Name: "Veteran", Description: "Play 50 games"},
+}
+
+type UserAchievement struct {
+	UserID     uint      `json:"userId" gorm:"primaryKey"`
+	Code       string    `json:"code" gorm:"primaryKey"`
+	UnlockedAt time.Time `json:"unlockedAt" gorm:"not null"`
+}
+
+type AchievementResponse struct {
+	Achievement
+	UnlockedAt *time.Time `json:"unlockedAt"`
+}
@@ -0,0 +1,11 @@
+package models
+
+import "time"
+
+// Block hides the blocked user from the blocker: no messages, no game
+// invitations and no friend requests in either direction.
+type Block struct {
+	BlockerID uint      `json:"blockerId" gorm:"primaryKey"`
+	BlockedID uint      `json:"blockedId" gorm:"primaryKey;index"`
+	CreatedAt time.Time `json:"createdAt"`
+}
@@ -0,0 +1,70 @@
+package models
+
+import "time"
+
+const (
+	ChannelPublic    = "public"
+	ChannelPrivate   = "private"
+	ChannelProtected = "protected"
+
+	ChannelRoleOwner  = "owner"
+	ChannelRoleAdmin  = "admin"
+	ChannelRoleMember = "member"
+
+	ChannelBan  = "ban"
+	ChannelMute = "mute"
+)
+
+// Channel is a group conversation. Public channels are open to everyone,
+// protected ones ask for the password and private ones are joined on
+// invitation only.
+type Channel struct {
+	ID         uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	Name       string    `json:"name" gorm:"unique;not null"`
+	Visibility string    `json:"visibility" gorm:"not null;default:public"`
+	Password   string    `json:"-"`
+	OwnerID    uint      `json:"ownerId" gorm:"not null"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+type ChannelMember struct {
+	ChannelID uint      `json:"channelId" gorm:"primaryKey"`
+	UserID    uint      `json:"userId" gorm:"primaryKey;index"`
+	Role      string    `json:"role" gorm:"not null;default:member"`
+	JoinedAt  time.Time `json:"joinedAt"`
+}
+
+type ChannelMessage struct {
+	ID        uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	ChannelID uint      `json:"channelId" gorm:"not null;index:idx_channel_messages_channel,priority:1"`
+	SenderID  uint      `json:"senderId" gorm:"not null"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"createdAt" gorm:"index:idx_channel_messages_channel,priority:2"`
+}
+
+// ChannelSanction keeps a user out of one channel (ban) or keeps them from
+// posting in it (mute). A nil Until lasts until a channel admin lifts it.
+type ChannelSanction struct {
+	ID        uint       `json:"id" gorm:"primaryKey;autoIncrement"`
+	ChannelID uint       `json:"channelId" gorm:"not null;uniqueIndex:idx_channel_sanction"`
+	UserID    uint       `json:"userId" gorm:"not null;uniqueIndex:idx_channel_sanction"`
+	Kind      string     `json:"kind" gorm:"not null;uniqueIndex:idx_channel_sanction"`
+	IssuedBy  uint       `json:"issuedBy" gorm:"not null"`
+	Until     *time.Time `json:"until"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+type CreateChannelDto struct {
+	Name       string `json:"name" binding:"required,min=3,max=32"`
+	Visibility string `json:"visibility" binding:"required,oneof=public private protected"`
+	Password   string `json:"password" binding:"omitempty,min=4,max=50"`
+}
+
+type UpdateChannelDto struct {
+	Visibility string `json:"visibility" binding:"required,oneof=public private protected"`
+	Password   string `json:"password" binding:"omitempty,min=4,max=50"`
+}
+
+type ChannelSanctionDto struct {
+	Until *time.Time `json:"until"`
+}
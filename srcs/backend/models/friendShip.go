@@ -8,10 +8,24 @@ type FriendShip struct {
 	MutualFriends bool `gorm:"not null"`
 }
 
+// Message is paginated per conversation on (created_at, id), the composite
+// index below serves that lookup. A deleted message keeps its row so the
+// conversation still shows where it was, but its content is wiped.
 type Message struct {
-	ID         uint      `json:"id" gorm:"primaryKey;autoIncrement"`
-	SenderID   uint      `json:"senderId" gorm:"not null"`
-	ReceiverID uint      `json:"receiverId" gorm:"not null"`
-	Content    string    `json:"content"`
-	CreatedAt  time.Time `json:"createdAt"`
+	ID          uint              `json:"id" gorm:"primaryKey;autoIncrement"`
+	SenderID    uint              `json:"senderId" gorm:"not null;index:idx_messages_conversation,priority:1"`
+	ReceiverID  uint              `json:"receiverId" gorm:"not null;index:idx_messages_conversation,priority:2"`
+	Content     string            `json:"content"`
+	CreatedAt   time.Time         `json:"createdAt" gorm:"index:idx_messages_conversation,priority:3"`
+	DeliveredAt *time.Time        `json:"deliveredAt"`
+	ReadAt      *time.Time        `json:"readAt"`
+	EditedAt    *time.Time        `json:"editedAt"`
+	DeletedAt   *time.Time        `json:"deletedAt"`
+	EditHistory []MessageRevision `json:"editHistory" gorm:"serializer:json"`
+}
+
+// MessageRevision is a previous content of an edited message.
+type MessageRevision struct {
+	Content  string    `json:"content"`
+	EditedAt time.Time `json:"editedAt"`
 }
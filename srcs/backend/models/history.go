@@ -9,8 +9,30 @@ type GameHistory struct {
     WinnerID  uint64   `json:"winner_id" gorm:"not null"`
     Score1    int      `json:"score1"`
     Score2    int      `json:"score2"`
+    IsTournamentGame bool `json:"is_tournament_game"`
+    IsGameMode       bool `json:"is_game_mode"`
+    Duration float64 `json:"duration"` // in seconds
+    Player1Boosts int `json:"player1_boosts"`
+    Player2Boosts int `json:"player2_boosts"`
+    Rules    GameRules `json:"rules" gorm:"serializer:json"`
+    Forfeit  bool    `json:"forfeit"` // a player left before the end
+
+    Player1RatingBefore float64 `json:"player1_rating_before"`
+    Player1RatingAfter  float64 `json:"player1_rating_after"`
+    Player2RatingBefore float64 `json:"player2_rating_before"`
+    Player2RatingAfter  float64 `json:"player2_rating_after"`
 
     Player1 User `json:"player1" gorm:"foreignKey:Player1ID"`
     Player2 User `json:"player2" gorm:"foreignKey:Player2ID"`
     Winner User  `json:"winner" gorm:"foreignKey:WinnerID"`
-}
\ No newline at end of file
+}
+// GameRules is the ruleset the players agreed on in the lobby, older games
+// were played with the defaults of the time and have it empty.
+type GameRules struct {
+    WinningScore     uint8   `json:"winningScore"`
+    BallSpeed        float64 `json:"ballSpeed"`
+    PaddleHeight     float64 `json:"paddleHeight"`
+    PaddleSpeed      float64 `json:"paddleSpeed"`
+    CollisionToBoost int     `json:"collisionToBoost"`
+    BoostMultiplier  float64 `json:"boostMultiplier"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+const (
+	LeaderboardAllTime = "all"
+	LeaderboardMonth   = "month"
+	LeaderboardWeek    = "week"
+)
+
+// LeaderboardEntry caches a player's results over one period so rankings
+// do not scan the whole game history. It is refreshed with every saved game,
+// the all time period starts at the zero time.
+type LeaderboardEntry struct {
+	UserID          uint      `json:"userId" gorm:"primaryKey"`
+	Period          string    `json:"period" gorm:"primaryKey"`
+	PeriodStart     time.Time `json:"periodStart" gorm:"primaryKey"`
+	Wins            int64     `json:"wins" gorm:"not null;default:0"`
+	Losses          int64     `json:"losses" gorm:"not null;default:0"`
+	PointDifference int64     `json:"pointDifference" gorm:"not null;default:0"`
+}
+
+// LeaderboardRow is one ranked player, rows come ordered by wins, win rate,
+// point difference and user id.
+type LeaderboardRow struct {
+	Rank            int     `json:"rank"`
+	ID              uint    `json:"id"`
+	DisplayName     string  `json:"displayname"`
+	Nickname        string  `json:"nickname"`
+	Avatar          string  `json:"avatar"`
+	Wins            int64   `json:"wins"`
+	Losses          int64   `json:"losses"`
+	WinRate         float64 `json:"winRate"`
+	PointDifference int64   `json:"pointDifference"`
+}
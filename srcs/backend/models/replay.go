@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// GameReplay holds what the hub needs to re-simulate a game: the seed and
+// the players' inputs with the physics tick each one applied on.
+type GameReplay struct {
+	ID            uint          `json:"id" gorm:"primaryKey;autoIncrement"`
+	GameHistoryID uint          `json:"gameId" gorm:"not null;uniqueIndex"`
+	Seed          int64         `json:"seed"`
+	TickRateMs    int64         `json:"tickRateMs"`
+	Ticks         uint64        `json:"ticks"`
+	IsGameMode    bool          `json:"isGameMode"`
+	Rules         GameRules     `json:"rules" gorm:"serializer:json"`
+	Inputs        []ReplayInput `json:"inputs" gorm:"serializer:json"`
+	CreatedAt     time.Time     `json:"createdAt"`
+}
+
+// ReplayInput is an UP, DOWN, STOP or SPACE command of a player, or LEAVE when
+// they left the game and forfeited it.
+type ReplayInput struct {
+	Tick    uint64 `json:"tick"`
+	Player  int    `json:"player"`
+	Command string `json:"command"`
+}
@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+const (
+	ReportOpen      = "open"
+	ReportInReview  = "in_review"
+	ReportResolved  = "resolved"
+	ReportDismissed = "dismissed"
+)
+
+// Report is a player complaint about another user, optionally pointing at
+// the message or the game it is about. Admins work through them in the
+// moderation queue.
+type Report struct {
+	ID         uint       `json:"id" gorm:"primaryKey;autoIncrement"`
+	ReporterID uint       `json:"reporterId" gorm:"not null"`
+	ReportedID uint       `json:"reportedId" gorm:"not null;index"`
+	Reason     string     `json:"reason" gorm:"not null"`
+	Details    string     `json:"details"`
+	MessageID  *uint      `json:"messageId"`
+	GameID     *uint      `json:"gameId"`
+	Status     string     `json:"status" gorm:"not null;default:open;index"`
+	HandledBy  *uint      `json:"handledBy"`
+	Resolution string     `json:"resolution"`
+	ResolvedAt *time.Time `json:"resolvedAt"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	UpdatedAt  time.Time  `json:"updatedAt"`
+}
+
+type CreateReportDto struct {
+	UserID    uint   `json:"userId" binding:"required"`
+	Reason    string `json:"reason" binding:"required,oneof=harassment cheating spam inappropriate other"`
+	Details   string `json:"details" binding:"max=1000"`
+	MessageID *uint  `json:"messageId"`
+	GameID    *uint  `json:"gameId"`
+}
+
+type UpdateReportDto struct {
+	Status     string `json:"status" binding:"required,oneof=open in_review resolved dismissed"`
+	Resolution string `json:"resolution" binding:"max=1000"`
+}
+
+type SuspendUserDto struct {
+	Until  time.Time `json:"until" binding:"required"`
+	Reason string    `json:"reason" binding:"max=255"`
+}
@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+const (
+	SanctionMute    = "mute"
+	SanctionBan     = "ban"
+	SanctionSuspend = "suspend"
+)
+
+// Sanction keeps a user out of the chat (mute), out of the chat and the
+// tournaments (ban) or out of their account (suspend) until the given time,
+// or until an admin lifts it. A suspension is mirrored on
+// User.SuspendedUntil which is checked on every request.
+type Sanction struct {
+	ID        uint       `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID    uint       `json:"userId" gorm:"not null;index"`
+	Kind      string     `json:"kind" gorm:"not null"`
+	Reason    string     `json:"reason"`
+	IssuedBy  uint       `json:"issuedBy" gorm:"not null"`
+	Until     time.Time  `json:"until" gorm:"not null"`
+	LiftedAt  *time.Time `json:"liftedAt"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+// SanctionAudit records every change made to the sanctions, rows are never
+// updated nor deleted.
+type SanctionAudit struct {
+	ID         uint      `json:"id" gorm:"primaryKey;autoIncrement"`
+	SanctionID uint      `json:"sanctionId" gorm:"not null;index"`
+	ActorID    uint      `json:"actorId" gorm:"not null"`
+	Action     string    `json:"action" gorm:"not null"`
+	UserID     uint      `json:"userId" gorm:"not null"`
+	Kind       string    `json:"kind" gorm:"not null"`
+	Until      time.Time `json:"until"`
+	Reason     string    `json:"reason"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+type CreateSanctionDto struct {
+	UserID uint      `json:"userId" binding:"required"`
+	Kind   string    `json:"kind" binding:"required,oneof=mute ban"`
+	Until  time.Time `json:"until" binding:"required"`
+	Reason string    `json:"reason" binding:"max=255"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// Session is one login of a user on a device, every refresh token rotated from
+// that login belongs to it.
+type Session struct {
+	ID         string     `json:"id" gorm:"primaryKey"`
+	UserID     uint       `json:"-" gorm:"not null;index"`
+	UserAgent  string     `json:"userAgent"`
+	IP         string     `json:"ip"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	LastSeenAt time.Time  `json:"lastSeenAt"`
+	RevokedAt  *time.Time `json:"-"`
+}
+
+// RefreshToken rows of a same session come from successive rotations, reusing
+// a rotated token revokes the whole session.
+type RefreshToken struct {
+	ID        uint       `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID    uint       `json:"userId" gorm:"not null;index"`
+	SessionID string     `json:"sessionId" gorm:"not null;index"`
+	TokenHash string     `json:"-" gorm:"not null;uniqueIndex"`
+	AccessJTI string     `json:"-" gorm:"not null"`
+	ExpiresAt time.Time  `json:"expiresAt" gorm:"not null"`
+	RevokedAt *time.Time `json:"revokedAt"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+// RevokedToken is the deny list of access tokens checked by middleware.Token(),
+// rows are useless once the token itself expired.
+type RevokedToken struct {
+	JTI       string    `json:"jti" gorm:"primaryKey"`
+	ExpiresAt time.Time `json:"expiresAt" gorm:"not null;index"`
+}
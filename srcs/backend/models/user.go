@@ -1,12 +1,30 @@
 package models
 
+import "time"
+
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
 type User struct {
 	ID          uint    `json:"id" gorm:"primary_key;autoIncrement"`
 	DisplayName string  `json:"displayname" gorm:"not null" binding:"required,min=3" validate:"required,min=3,max=16"`
 	Nickname    string  `json:"nickname" gorm:"unique;not null" binding:"required,min=3" validate:"required,min=3,max=16"`
 	Password    string  `json:"password" gorm:"not null" binding:"required,min=6" validate:"required,min=6"`
 	Avatar      string  `json:"avatar"`
+	Role        string  `json:"role" gorm:"not null;default:user"`
 	Friends     []*User `gorm:"many2many:friendShip;"`
+
+	Rating          float64 `json:"rating" gorm:"not null;default:1500"`
+	RatingDeviation float64 `json:"ratingDeviation" gorm:"not null;default:350"`
+
+	SuspendedUntil *time.Time `json:"-"`
+}
+
+func (u *User) IsSuspended() bool {
+	return u.SuspendedUntil != nil && u.SuspendedUntil.After(time.Now())
 }
 
 type TwoFactorAuth struct {
@@ -21,6 +39,10 @@ type UserResponse struct {
 	DisplayName string `json:"displayname"`
 	Nickname    string `json:"nickname"`
 	Avatar      string `json:"avatar"`
+	Role        string `json:"role,omitempty"`
+
+	Rating          float64 `json:"rating,omitempty"`
+	RatingDeviation float64 `json:"ratingDeviation,omitempty"`
 }
 
 type CreateUserDto struct {
@@ -0,0 +1,23 @@
+package utils
+
+import (
+	"log"
+	"os"
+	"time"
+)
+
+// DurationFromEnv reads a duration such as "15m" from the environment, the
+// fallback is used when the variable is unset or invalid.
+func DurationFromEnv(name string, fallback time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration < 0 {
+		log.Printf("invalid %s %q, using %s", name, value, fallback)
+		return fallback
+	}
+	return duration
+}
@@ -3,29 +3,57 @@ package utils
 import (
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
+const (
+	AccessTokenTTL  = 15 * time.Minute
+	RefreshTokenTTL = 7 * 24 * time.Hour
+)
+
+// websocket/utils/jwt.go decodes these tokens too, keep both in sync.
+// Role is only trusted until the token expires, which is why changing a role
+// ends the user's sessions.
 type UserToken struct {
-	ID uint
+	ID        uint
+	Role      string
+	SessionID string
+	JTI       string
+	ExpiresAt time.Time
 }
 
-func CreateToken(id uint) (string, error) {
+func CreateToken(id uint, role string, sessionId string) (string, *UserToken, error) {
+	jti, err := GenerateRandomToken()
+	if err != nil {
+		return "", nil, err
+	}
+
+	now := time.Now()
 	userToken := UserToken{
-		ID: id,
+		ID:        id,
+		Role:      role,
+		SessionID: sessionId,
+		JTI:       jti,
+		ExpiresAt: now.Add(AccessTokenTTL),
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"ID": userToken.ID,
+		"ID":   userToken.ID,
+		"role": userToken.Role,
+		"sid":  userToken.SessionID,
+		"jti":  userToken.JTI,
+		"iat":  now.Unix(),
+		"exp":  userToken.ExpiresAt.Unix(),
 	})
 
 	tokenString, err := token.SignedString([]byte(os.Getenv("JWT_SIGNATURE")))
 	if err != nil {
-		return "", err
+		return "", nil, err
 	}
 
-	return tokenString, nil
+	return tokenString, &userToken, nil
 }
 
 func DecryptToken(AccessToken string) (*UserToken, error) {
@@ -35,7 +63,7 @@ func DecryptToken(AccessToken string) (*UserToken, error) {
 			return nil, fmt.Errorf("Unexpected signature method: %v", token.Header["alg"])
 		}
 		return key, nil
-	})
+	}, jwt.WithExpirationRequired(), jwt.WithIssuedAt())
 
 	if err != nil {
 		return nil, err
@@ -47,8 +75,32 @@ func DecryptToken(AccessToken string) (*UserToken, error) {
 			return nil, fmt.Errorf("ID manquant ou de type incorrect")
 		}
 
+		role, ok := claims["role"].(string)
+		if !ok || role == "" {
+			return nil, fmt.Errorf("role manquant ou de type incorrect")
+		}
+
+		sid, ok := claims["sid"].(string)
+		if !ok || sid == "" {
+			return nil, fmt.Errorf("sid manquant ou de type incorrect")
+		}
+
+		jti, ok := claims["jti"].(string)
+		if !ok || jti == "" {
+			return nil, fmt.Errorf("jti manquant ou de type incorrect")
+		}
+
+		exp, err := claims.GetExpirationTime()
+		if err != nil {
+			return nil, err
+		}
+
 		return &UserToken{
-			ID: uint(id),
+			ID:        uint(id),
+			Role:      role,
+			SessionID: sid,
+			JTI:       jti,
+			ExpiresAt: exp.Time,
 		}, nil
 	}
 	return nil, fmt.Errorf("Invalid token")
@@ -0,0 +1,61 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const WebsocketURL = "http://websocket:4001"
+
+var websocketClient = &http.Client{Timeout: 5 * time.Second}
+
+// PushEvent asks the websocket hub to forward the event to the recipients that
+// are currently connected, offline ones simply miss it.
+func PushEvent(recipients []uint, event interface{}) error {
+	return sendToHub(map[string]interface{}{
+		"recipients": recipients,
+		"event":      event,
+	})
+}
+
+// DisconnectUsers forwards the event like PushEvent, then closes every
+// connection the recipients have.
+func DisconnectUsers(recipients []uint, event interface{}) error {
+	return sendToHub(map[string]interface{}{
+		"recipients": recipients,
+		"event":      event,
+		"disconnect": true,
+	})
+}
+
+func sendToHub(payload interface{}) error {
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", WebsocketURL+"/internal/events", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return err
+	}
+
+	token, err := CreateServiceToken()
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := websocketClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		return fmt.Errorf("received non-202 response: %s", resp.Status)
+	}
+	return nil
+}
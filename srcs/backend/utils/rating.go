@@ -0,0 +1,50 @@
+package utils
+
+import "math"
+
+// Ratings follow Glicko-1: every player has a rating and a deviation telling
+// how sure we are about it. The deviation shrinks as games are played, so
+// new players move fast and settle down over time.
+const (
+	DefaultRating          = 1500.0
+	DefaultRatingDeviation = 350.0
+	MinRatingDeviation     = 30.0
+
+	// Tournament games weigh more on the rating change.
+	TournamentRatingFactor = 1.5
+)
+
+const glickoQ = math.Ln10 / 400
+
+type Rating struct {
+	Value     float64
+	Deviation float64
+}
+
+func glickoG(deviation float64) float64 {
+	return 1 / math.Sqrt(1+3*glickoQ*glickoQ*deviation*deviation/(math.Pi*math.Pi))
+}
+
+// rate computes the new rating of player after one game against opponent,
+// score being 1 for a win and 0 for a loss.
+func rate(player Rating, opponent Rating, score float64, factor float64) Rating {
+	g := glickoG(opponent.Deviation)
+	expected := 1 / (1 + math.Pow(10, -g*(player.Value-opponent.Value)/400))
+	dSquared := 1 / (glickoQ * glickoQ * g * g * expected * (1 - expected))
+	precision := 1/(player.Deviation*player.Deviation) + 1/dSquared
+
+	return Rating{
+		Value:     player.Value + factor*glickoQ/precision*g*(score-expected),
+		Deviation: math.Max(math.Sqrt(1/precision), MinRatingDeviation),
+	}
+}
+
+// RateGame returns the ratings of both players after the winner beat the
+// loser.
+func RateGame(winner Rating, loser Rating, isTournamentGame bool) (Rating, Rating) {
+	factor := 1.0
+	if isTournamentGame {
+		factor = TournamentRatingFactor
+	}
+	return rate(winner, loser, 1, factor), rate(loser, winner, 0, factor)
+}
@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRateGame(t *testing.T) {
+	newPlayer := Rating{Value: DefaultRating, Deviation: DefaultRatingDeviation}
+
+	tests := []struct {
+		name             string
+		winner, loser    Rating
+		isTournamentGame bool
+		wantWinner       Rating
+		wantLoser        Rating
+	}{
+		{
+			// Two new players, the reference single game of Glicko-1.
+			name:       "new players",
+			winner:     newPlayer,
+			loser:      newPlayer,
+			wantWinner: Rating{Value: 1662.2, Deviation: 290.2},
+			wantLoser:  Rating{Value: 1337.8, Deviation: 290.2},
+		},
+		{
+			name:             "new players in a tournament",
+			winner:           newPlayer,
+			loser:            newPlayer,
+			isTournamentGame: true,
+			wantWinner:       Rating{Value: 1743.3, Deviation: 290.2},
+			wantLoser:        Rating{Value: 1256.7, Deviation: 290.2},
+		},
+		{
+			name:       "favourite beats a settled underdog",
+			winner:     Rating{Value: 1500, Deviation: 200},
+			loser:      Rating{Value: 1400, Deviation: 30},
+			wantWinner: Rating{Value: 1563.4, Deviation: 175.2},
+			wantLoser:  Rating{Value: 1398.3, Deviation: MinRatingDeviation},
+		},
+		{
+			name:       "settled player beats a newcomer",
+			winner:     Rating{Value: 2000, Deviation: 30},
+			loser:      newPlayer,
+			wantWinner: Rating{Value: 2000.4, Deviation: MinRatingDeviation},
+			wantLoser:  Rating{Value: 1468.6, Deviation: 318.8},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			winner, loser := RateGame(tt.winner, tt.loser, tt.isTournamentGame)
+			assertRating(t, "winner", winner, tt.wantWinner)
+			assertRating(t, "loser", loser, tt.wantLoser)
+		})
+	}
+}
+
+func TestTournamentRatingFactor(t *testing.T) {
+	winner := Rating{Value: 1620, Deviation: 80}
+	loser := Rating{Value: 1580, Deviation: 120}
+
+	casualWinner, casualLoser := RateGame(winner, loser, false)
+	tournamentWinner, tournamentLoser := RateGame(winner, loser, true)
+
+	if gain, want := tournamentWinner.Value-winner.Value, (casualWinner.Value-winner.Value)*TournamentRatingFactor; math.Abs(gain-want) > 1e-9 {
+		t.Errorf("tournament gain %.4f, want %.4f", gain, want)
+	}
+	if loss, want := tournamentLoser.Value-loser.Value, (casualLoser.Value-loser.Value)*TournamentRatingFactor; math.Abs(loss-want) > 1e-9 {
+		t.Errorf("tournament loss %.4f, want %.4f", loss, want)
+	}
+	if tournamentWinner.Deviation != casualWinner.Deviation || tournamentLoser.Deviation != casualLoser.Deviation {
+		t.Errorf("the factor must not change the deviations")
+	}
+}
+
+func assertRating(t *testing.T, who string, got Rating, want Rating) {
+	t.Helper()
+	if math.Abs(got.Value-want.Value) > 0.1 || math.Abs(got.Deviation-want.Deviation) > 0.1 {
+		t.Errorf("%s rated %.1f (%.1f), want %.1f (%.1f)", who, got.Value, got.Deviation, want.Value, want.Deviation)
+	}
+}
@@ -0,0 +1,64 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	ServiceName     = "backend"
+	ServiceTokenTTL = time.Minute
+)
+
+// Internal calls between our services are authenticated with a short lived
+// token signed with SERVICE_SIGNATURE, a secret end users never see. The
+// "svc" claim names the calling service and never appears in user tokens.
+// websocket/utils/service.go is the mirror of this file, keep both in sync.
+type ServiceToken struct {
+	Service string
+}
+
+func CreateServiceToken() (string, error) {
+	key := []byte(os.Getenv("SERVICE_SIGNATURE"))
+	if len(key) == 0 {
+		return "", fmt.Errorf("SERVICE_SIGNATURE is not configured")
+	}
+
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"svc": ServiceName,
+		"iat": now.Unix(),
+		"exp": now.Add(ServiceTokenTTL).Unix(),
+	})
+	return token.SignedString(key)
+}
+
+func DecryptServiceToken(serviceToken string) (*ServiceToken, error) {
+	key := []byte(os.Getenv("SERVICE_SIGNATURE"))
+	if len(key) == 0 {
+		return nil, fmt.Errorf("SERVICE_SIGNATURE is not configured")
+	}
+
+	token, err := jwt.Parse(serviceToken, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("Unexpected signature method: %v", token.Header["alg"])
+		}
+		return key, nil
+	}, jwt.WithExpirationRequired(), jwt.WithIssuedAt())
+
+	if err != nil {
+		return nil, err
+	}
+
+	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
+		service, ok := claims["svc"].(string)
+		if !ok || service == "" {
+			return nil, fmt.Errorf("svc manquant ou de type incorrect")
+		}
+		return &ServiceToken{Service: service}, nil
+	}
+	return nil, fmt.Errorf("Invalid token")
+}
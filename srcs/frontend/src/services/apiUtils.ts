@@ -6,8 +6,29 @@ export const API_BASE_URL = `${getBaseHost()}${import.meta.env.PROD ? '/api' : '
 //  ? 'https://localhost:8443/api'
 //  : 'http://localhost:4000' 
 
+let refreshPromise: Promise<boolean> | null = null;
+
+// Access tokens are short lived: on a 401 we rotate the refresh token once
+// (shared between concurrent requests) and replay the original request.
+async function refreshAccessToken(): Promise<boolean> {
+    if (!refreshPromise) {
+        refreshPromise = fetch(`${API_BASE_URL}/auth/refresh`, {
+            method: 'POST',
+            credentials: 'include',
+        })
+            .then(response => response.ok)
+            .catch(() => false)
+            .finally(() => { refreshPromise = null; });
+    }
+    return refreshPromise;
+}
+
 export async function apiRequest<T>(url: string, options: RequestInit): Promise<T> {
-    const response = await fetch(`${API_BASE_URL}${url}`, options);
+    let response = await fetch(`${API_BASE_URL}${url}`, options);
+
+    if (response.status === 401 && !url.startsWith('/auth/') && await refreshAccessToken()) {
+        response = await fetch(`${API_BASE_URL}${url}`, options);
+    }
 
     let result;
     
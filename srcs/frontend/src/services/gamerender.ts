@@ -86,6 +86,13 @@ export function drawBall(ctx: CanvasRenderingContext2D, state: GameState) {
     ctx.fillStyle = 'rgba(255, 255, 255 , 0.7)';
     ctx.arc(state.ball.x, state.ball.y, 10, 0, Math.PI * 2);
     ctx.fill();
+
+    // Balles supplementaires du mode special
+    state.extraBalls?.forEach((ball) => {
+        ctx.beginPath();
+        ctx.arc(ball.x, ball.y, 10, 0, Math.PI * 2);
+        ctx.fill();
+    });
     
     // Dessiner les indicateurs de boost
     ctx.globalCompositeOperation = 'source-over';
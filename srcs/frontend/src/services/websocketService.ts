@@ -1,5 +1,5 @@
 import { OnlineUsersMessage, UserStatusMessage } from '../types/connection_status';
-import { ChatMessage } from '../types/chat';
+import { ChatMessage, ChatSync, ChatSyncDone } from '../types/chat';
 import { UserData } from '../types/models';
 import { LobbyInvitationToFriend, LobbyInvitationFromFriend, LobbyAcceptFromFriend, LobbyDenyFromFriend, LobbyCreated, LobbyPlayerStatus, LobbyPregameRemainingTime, LobbyTerminate, LobbyDestroyed, LobbySpecialModeToggled  } from '../types/lobby';
 import {TournamentStart, TournamentCreate, TournamentJoinWithCode, TournamentLeave, TournamentLeaveWaitingRoom, TournamentTimer, TournamentGame, TournamentError, TournamentTreeState, TournamentEvent, TournamentTerminate } from '../types/tournament';
@@ -52,6 +52,8 @@ export class WebSocketService {
     public onlineUsersStore: ReturnType<typeof useOnlineUsersStore>;
     public userStore: IUserStore;
     public messageHandlers: MessageHandlers = {};
+    // Highest message id received, sent on reconnect so the hub replays what was missed
+    public lastMessageId: number | null = null;
 
     constructor(clientId: number, onlineUsersStore: ReturnType<typeof useOnlineUsersStore>, userStore: IUserStore) {
         this.clientId = clientId;
@@ -60,8 +62,15 @@ export class WebSocketService {
         this.initMessageHandlers();
     }
 
+    public trackMessageId(messageId: number | undefined): void {
+        if (messageId && (this.lastMessageId === null || messageId > this.lastMessageId)) {
+            this.lastMessageId = messageId;
+        }
+    }
+
     public initMessageHandlers(): void {
         this.setMessageHandler<ChatMessage>('CHAT', (message: ChatMessage) => {
+            this.trackMessageId(message.messageId);
             const conversationId = message.senderID === this.clientId
                 ? message.receiverID
                 : message.senderID;
@@ -77,6 +86,18 @@ export class WebSocketService {
             }
         });
 
+        this.setMessageHandler<ChatSyncDone>('CHAT_SYNC_DONE', (message: ChatSyncDone) => {
+            this.trackMessageId(message.lastMessageId);
+            if (message.hasMore && this.ws && this.ws.readyState === WebSocket.OPEN) {
+                const sync: ChatSync = {
+                    type: 'CHAT_SYNC',
+                    userId: this.clientId,
+                    lastMessageId: message.lastMessageId
+                };
+                this.ws.send(JSON.stringify(sync));
+            }
+        });
+
         this.setMessageHandler<OnlineUsersMessage>('ONLINE_USERS', (message: OnlineUsersMessage) => {
             this.onlineUsersStore.setOnlineUsers(message.usersOnline);
         });
@@ -153,7 +174,8 @@ export class WebSocketService {
 
     public connect(): void {
         try {
-            const url = WS_URL + `?id=${this.clientId}`
+            // The access_token cookie authenticates the upgrade
+            const url = this.lastMessageId !== null ? `${WS_URL}?lastMessageId=${this.lastMessageId}` : WS_URL;
             this.ws = new WebSocket(url);
             this.ws.onopen = () => {
                 console.log('Websocket connected!');
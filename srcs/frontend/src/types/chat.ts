@@ -1,6 +1,20 @@
 export interface ChatMessage {
   type: 'CHAT';
+  messageId?: number;
   data: string;
   senderID: number;
   receiverID: number;
 }
+
+export interface ChatSync {
+  type: 'CHAT_SYNC';
+  userId: number;
+  lastMessageId: number;
+}
+
+export interface ChatSyncDone {
+  type: 'CHAT_SYNC_DONE';
+  userId: number;
+  lastMessageId: number;
+  hasMore: boolean;
+}
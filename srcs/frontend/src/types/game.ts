@@ -48,12 +48,13 @@ export interface Score {
 
 export interface GameState {
     ball: Ball;
+    extraBalls?: Ball[] | null;
     paddle?: Paddle;
     score: Score;
     isGameMode: boolean;
     winner: number;
     isPaused: boolean;
-    pauseTime: string; 
+    pauseTicks: number;
     player1boost: BoostState;  
     player2boost: BoostState;
     elapsedTime: number;
@@ -0,0 +1,135 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+	"websocket/utils"
+)
+
+const BackendURL = "http://backend:4000"
+
+var backendClient = &http.Client{Timeout: 5 * time.Second}
+
+// RequestBackend calls one of the backend internal routes, authenticated with
+// a freshly signed service token. The caller owns the response body.
+func RequestBackend(method string, path string, payload interface{}) (*http.Response, error) {
+	body := &bytes.Buffer{}
+	if payload != nil {
+		jsonData, err := json.Marshal(payload)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewBuffer(jsonData)
+	}
+
+	req, err := http.NewRequest(method, BackendURL+"/internal"+path, body)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := utils.CreateServiceToken()
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	return backendClient.Do(req)
+}
+
+// BackendError turns a failed backend response into an error carrying the
+// reason the backend gave, it is meant to be shown to the user.
+func BackendError(resp *http.Response) error {
+	var failure struct {
+		Error string `json:"error"`
+	}
+	json.NewDecoder(resp.Body).Decode(&failure)
+	if failure.Error == "" {
+		failure.Error = resp.Status
+	}
+	return fmt.Errorf("%s", failure.Error)
+}
+
+type Relation struct {
+	Friends bool `json:"friends"`
+	Blocked bool `json:"blocked"`
+}
+
+// GetRelation asks the backend how userId relates to targetId.
+func GetRelation(userId uint64, targetId uint64) (*Relation, error) {
+	resp, err := RequestBackend("GET", fmt.Sprintf("/relations/%d/%d", userId, targetId), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("received non-200 response: %s", resp.Status)
+	}
+
+	var relation Relation
+	if err := json.NewDecoder(resp.Body).Decode(&relation); err != nil {
+		return nil, err
+	}
+	return &relation, nil
+}
+
+type Sanctions struct {
+	MutedUntil     *time.Time `json:"mutedUntil"`
+	BannedUntil    *time.Time `json:"bannedUntil"`
+	SuspendedUntil *time.Time `json:"suspendedUntil"`
+}
+
+// GetSanctions asks the backend which sanctions are running against the user.
+func GetSanctions(userId uint64) (*Sanctions, error) {
+	resp, err := RequestBackend("GET", fmt.Sprintf("/sanctions/%d", userId), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("received non-200 response: %s", resp.Status)
+	}
+
+	var sanctions Sanctions
+	if err := json.NewDecoder(resp.Body).Decode(&sanctions); err != nil {
+		return nil, err
+	}
+	return &sanctions, nil
+}
+
+// CheckNotMuted returns the reason the user may not talk right now, if any.
+func CheckNotMuted(userId uint64) error {
+	sanctions, err := GetSanctions(userId)
+	if err != nil {
+		return err
+	}
+	if sanctions.MutedUntil != nil {
+		return fmt.Errorf("You are muted until %s", sanctions.MutedUntil.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// CheckNotBanned returns the reason the user may not join a game right now,
+// if any.
+func CheckNotBanned(userId uint64) error {
+	sanctions, err := GetSanctions(userId)
+	if err != nil {
+		return err
+	}
+	if sanctions.BannedUntil != nil {
+		return fmt.Errorf("You are banned until %s", sanctions.BannedUntil.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// WhenNotBanned runs CheckNotBanned outside of the hub goroutine, then calls
+// then on the hub with its result.
+func (h *Hub) WhenNotBanned(userId uint64, then func(err error)) {
+	h.Async(func() func() {
+		err := CheckNotBanned(userId)
+		return func() { then(err) }
+	})
+}
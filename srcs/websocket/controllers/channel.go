@@ -0,0 +1,87 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+	"websocket/models"
+)
+
+type SavedChannelMessage struct {
+	Message struct {
+		ID        uint64    `json:"id"`
+		CreatedAt time.Time `json:"createdAt"`
+	} `json:"message"`
+	Members []uint64 `json:"members"`
+}
+
+// SaveChannelMessageToDB stores the message through the backend, which checks
+// the sender belongs to the channel and returns who else does.
+func SaveChannelMessageToDB(event models.ChannelMessageEvent) (*SavedChannelMessage, error) {
+	resp, err := RequestBackend("POST", "/channels/message", map[string]interface{}{
+		"channelId": event.ChannelID,
+		"senderId":  event.SenderID,
+		"content":   event.Data,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return nil, BackendError(resp)
+	}
+
+	var saved SavedChannelMessage
+	if err := json.NewDecoder(resp.Body).Decode(&saved); err != nil {
+		return nil, err
+	}
+	return &saved, nil
+}
+
+func SendChannelError(h *Hub, event models.ChannelMessageEvent, reason string) {
+	sender, exists := h.Clients[event.SenderID]
+	if !exists {
+		return
+	}
+	event.Type = "CHANNEL_ERROR"
+	event.Error = reason
+	jsonData, _ := json.Marshal(&event)
+	safeSend(sender.Send, jsonData)
+}
+
+func HandleChannelMessage(h *Hub, message []byte) {
+	var event models.ChannelMessageEvent
+	if err := json.Unmarshal(message, &event); err != nil {
+		fmt.Printf("error parsing channel message: %v\n", err)
+		return
+	}
+
+	h.Enqueue(event.SenderID, func() func() {
+		if err := CheckNotMuted(event.SenderID); err != nil {
+			return func() { SendChannelError(h, event, err.Error()) }
+		}
+		saved, err := SaveChannelMessageToDB(event)
+		if err != nil {
+			fmt.Printf("Error on saving channel message in db: %v\n", err)
+			return func() { SendChannelError(h, event, err.Error()) }
+		}
+		return func() { relayChannelMessage(h, event, saved) }
+	})
+}
+
+func relayChannelMessage(h *Hub, event models.ChannelMessageEvent, saved *SavedChannelMessage) {
+	event.MessageID = saved.Message.ID
+	event.CreatedAt = &saved.Message.CreatedAt
+	jsonData, err := json.Marshal(&event)
+	if err != nil {
+		fmt.Printf("Impossible to parse ChannelMessageEvent type: %v\n", err)
+		return
+	}
+
+	for _, id := range saved.Members {
+		if member, exists := h.Clients[id]; exists {
+			safeSend(member.Send, jsonData)
+		}
+	}
+}
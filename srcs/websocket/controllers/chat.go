@@ -1,7 +1,6 @@
 package controllers
 
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -9,40 +8,44 @@ import (
 	"websocket/models"
 )
 
-func SaveMessageToDB(event models.MessageEvent) error {
+type SavedMessage struct {
+	ID        uint64    `json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// SaveMessageToDB persists the message through the backend, which checks the
+// two users may talk, and returns the stored row.
+func SaveMessageToDB(event models.MessageEvent, delivered bool) (*SavedMessage, error) {
 	var message struct {
-		SenderID   uint64    `json:"senderId"`
-		ReceiverID uint64    `json:"receiverId"`
-		Content    string    `json:"content"`
-		CreatedAt  time.Time `json:"createdAt"`
+		SenderID    uint64     `json:"senderId"`
+		ReceiverID  uint64     `json:"receiverId"`
+		Content     string     `json:"content"`
+		CreatedAt   time.Time  `json:"createdAt"`
+		DeliveredAt *time.Time `json:"deliveredAt"`
 	}
 
 	message.SenderID = event.SenderID
 	message.ReceiverID = event.ReceiverID
 	message.Content = event.Data
 	message.CreatedAt = time.Now()
-
-	jsonData, err := json.Marshal(message)
-	if err != nil {
-		return err
+	if delivered {
+		message.DeliveredAt = &message.CreatedAt
 	}
 
-	req, err := http.NewRequest("POST", "http://backend:4000/conversation/add", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return err
-	}
-	req.Header.Set("Content-Type", "application/json")
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	resp, err := RequestBackend("POST", "/conversation/add", message)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer resp.Body.Close()
 	if resp.StatusCode != http.StatusCreated {
-		return fmt.Errorf("received non-200 response: %s", resp.Status)
+		return nil, BackendError(resp)
 	}
 
-	return nil
+	var saved SavedMessage
+	if err := json.NewDecoder(resp.Body).Decode(&saved); err != nil {
+		return nil, err
+	}
+	return &saved, nil
 }
 
 func CreateOnlineUsersEvent(clients map[uint64]*Client, clientId uint64) models.OnlineUsersEvent {
@@ -90,22 +93,218 @@ func NotifyClients(h *Hub, clientId uint64, event string) {
 
 }
 
+func SendChatError(client *Client, receiverId uint64, reason string) {
+	event := models.ChatErrorEvent{
+		Event: models.Event{
+			Type: "CHAT_ERROR",
+		},
+		ReceiverID: receiverId,
+		Error:      reason,
+	}
+	jsonData, _ := json.Marshal(&event)
+	safeSend(client.Send, jsonData)
+}
+
+func CreateReceiptEvent(event string, userId uint64, friendId uint64, messageId uint64) []byte {
+	receipt := models.ReceiptEvent{
+		Event: models.Event{
+			Type: event,
+		},
+		UserId:    userId,
+		FriendId:  friendId,
+		MessageID: messageId,
+	}
+	jsonData, _ := json.Marshal(&receipt)
+	return jsonData
+}
+
+// HandleChatMessage stores the message first so it is relayed with its id,
+// then tells the sender whether it reached the receiver. The backend is asked
+// outside of the hub goroutine, in the order the sender wrote.
 func HandleChatMessage(h *Hub, message []byte) {
 	var event models.MessageEvent
 	if err := json.Unmarshal(message, &event); err != nil {
-		fmt.Printf("error parsing message: %v", err)
+		fmt.Printf("error parsing message: %v\n", err)
 		return
 	}
-	receiver, exists := h.Clients[event.ReceiverID]
-	if exists {
-		receiver.Send <- message
+	_, delivered := h.Clients[event.ReceiverID]
+
+	h.Enqueue(event.SenderID, func() func() {
+		if err := CheckNotMuted(event.SenderID); err != nil {
+			return func() { rejectChatMessage(h, event, err) }
+		}
+		saved, err := SaveMessageToDB(event, delivered)
+		if err != nil {
+			fmt.Printf("Error on saving data in db: %v\n", err)
+			return func() { rejectChatMessage(h, event, err) }
+		}
+		return func() { relayChatMessage(h, event, saved) }
+	})
+}
+
+func rejectChatMessage(h *Hub, event models.MessageEvent, err error) {
+	if sender, exists := h.Clients[event.SenderID]; exists {
+		SendChatError(sender, event.ReceiverID, err.Error())
 	}
-	sender, exists := h.Clients[event.SenderID]
-	if exists {
-		sender.Send <- message
+}
+
+func relayChatMessage(h *Hub, event models.MessageEvent, saved *SavedMessage) {
+	sender, senderExists := h.Clients[event.SenderID]
+	receiver, receiverExists := h.Clients[event.ReceiverID]
+
+	event.MessageID = saved.ID
+	event.CreatedAt = &saved.CreatedAt
+	jsonData, err := json.Marshal(&event)
+	if err != nil {
+		fmt.Printf("Impossible to parse MessageEvent type: %v\n", err)
+		return
+	}
+
+	if receiverExists {
+		safeSend(receiver.Send, jsonData)
 	}
-	err := SaveMessageToDB(event)
+	if senderExists {
+		safeSend(sender.Send, jsonData)
+		if receiverExists {
+			safeSend(sender.Send, CreateReceiptEvent("CHAT_DELIVERED", event.ReceiverID, event.SenderID, saved.ID))
+		}
+	}
+}
+
+// HandleChatRead persists that the reader saw the conversation up to the
+// given message and forwards the receipt to the friend who wrote it. It is
+// queued behind the reader's own messages, outside of the hub goroutine.
+func HandleChatRead(h *Hub, message []byte) {
+	var event models.ReceiptEvent
+	if err := json.Unmarshal(message, &event); err != nil {
+		fmt.Printf("error parsing read receipt: %v\n", err)
+		return
+	}
+
+	h.Enqueue(event.UserId, func() func() {
+		if err := SaveReadReceipt(event); err != nil {
+			fmt.Printf("Error on saving read receipt: %v\n", err)
+			return nil
+		}
+		return func() { relayChatRead(h, event) }
+	})
+}
+
+func SaveReadReceipt(event models.ReceiptEvent) error {
+	resp, err := RequestBackend("POST", "/conversation/read", map[string]uint64{
+		"userId":   event.UserId,
+		"friendId": event.FriendId,
+		"upTo":     event.MessageID,
+	})
 	if err != nil {
-		fmt.Printf("Error on saving data in db: %v\n", err)
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("received non-200 response: %s", resp.Status)
+	}
+	return nil
+}
+
+func relayChatRead(h *Hub, event models.ReceiptEvent) {
+	if friend, exists := h.Clients[event.FriendId]; exists {
+		safeSend(friend.Send, CreateReceiptEvent("CHAT_READ", event.UserId, event.FriendId, event.MessageID))
+	}
+}
+
+type MissedMessages struct {
+	Messages []struct {
+		ID         uint64    `json:"id"`
+		SenderID   uint64    `json:"senderId"`
+		ReceiverID uint64    `json:"receiverId"`
+		Content    string    `json:"content"`
+		CreatedAt  time.Time `json:"createdAt"`
+	} `json:"messages"`
+	Delivered []uint64 `json:"delivered"`
+	HasMore   bool     `json:"hasMore"`
+}
+
+func GetMissedMessages(userId uint64, lastMessageId uint64) (*MissedMessages, error) {
+	resp, err := RequestBackend("GET", fmt.Sprintf("/conversation/missed/%d?after=%d", userId, lastMessageId), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("received non-200 response: %s", resp.Status)
+	}
+
+	var missed MissedMessages
+	if err := json.NewDecoder(resp.Body).Decode(&missed); err != nil {
+		return nil, err
+	}
+	return &missed, nil
+}
+
+// ReplayMissedMessages pushes to the client, as regular CHAT events, every
+// message it did not see since lastMessageId. The backend is asked outside of
+// the hub goroutine, so live messages may reach the client before the replay;
+// the client keeps the highest id it saw.
+func ReplayMissedMessages(h *Hub, client *Client, lastMessageId uint64) {
+	h.Async(func() func() {
+		missed, err := GetMissedMessages(client.Id, lastMessageId)
+		if err != nil {
+			fmt.Printf("Error on fetching missed messages: %v\n", err)
+			return nil
+		}
+		return func() {
+			if h.Clients[client.Id] != client {
+				return
+			}
+			sendMissedMessages(h, client, missed, lastMessageId)
+		}
+	})
+}
+
+func sendMissedMessages(h *Hub, client *Client, missed *MissedMessages, lastMessageId uint64) {
+	authors := make(map[uint64]uint64)
+	for _, message := range missed.Messages {
+		event := models.MessageEvent{
+			Event: models.Event{
+				Type: "CHAT",
+			},
+			MessageID:  message.ID,
+			Data:       message.Content,
+			SenderID:   message.SenderID,
+			ReceiverID: message.ReceiverID,
+			CreatedAt:  &message.CreatedAt,
+		}
+		jsonData, _ := json.Marshal(&event)
+		safeSend(client.Send, jsonData)
+		authors[message.ID] = message.SenderID
+		lastMessageId = message.ID
+	}
+
+	for _, id := range missed.Delivered {
+		if sender, exists := h.Clients[authors[id]]; exists {
+			safeSend(sender.Send, CreateReceiptEvent("CHAT_DELIVERED", client.Id, authors[id], id))
+		}
+	}
+
+	done := models.SyncEvent{
+		Event: models.Event{
+			Type: "CHAT_SYNC_DONE",
+		},
+		UserId:        client.Id,
+		LastMessageID: lastMessageId,
+		HasMore:       missed.HasMore,
+	}
+	jsonData, _ := json.Marshal(&done)
+	safeSend(client.Send, jsonData)
+}
+
+func HandleChatSync(h *Hub, message []byte) {
+	var event models.SyncEvent
+	if err := json.Unmarshal(message, &event); err != nil {
+		fmt.Printf("error parsing sync request: %v\n", err)
+		return
+	}
+	if client, exists := h.Clients[event.UserId]; exists {
+		ReplayMissedMessages(h, client, event.LastMessageID)
 	}
 }
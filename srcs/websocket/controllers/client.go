@@ -2,7 +2,11 @@ package controllers
 
 import (
 	"bytes"
+	"encoding/json"
 	"log"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -24,9 +28,14 @@ const (
 
 type Client struct {
 	Id   uint64
+	Role string
 	Hub  *Hub
 	Conn *websocket.Conn
 	Send chan []byte
+
+	// Last message the client saw before reconnecting, given at upgrade
+	// time. Messages after it are replayed when the client registers.
+	LastMessageID *uint64
 }
 
 func (c *Client) ReadPump() {
@@ -50,6 +59,11 @@ func (c *Client) ReadPump() {
 		}
 		message = bytes.TrimSpace(bytes.Replace(message, []byte{'\n'}, []byte{' '}, -1))
 
+		message, err = c.StampIdentity(message)
+		if err != nil {
+			log.Printf("dropping malformed message from client %d: %v", c.Id, err)
+			continue
+		}
 		c.Hub.Broadcast <- message
 	}
 }
@@ -91,3 +105,34 @@ func (c *Client) WritePump() {
 		}
 	}
 }
+
+// HasRole tells whether the role from the client's access token is one of the
+// given ones, roles are the backend ones: user, moderator and admin.
+func (c *Client) HasRole(roles ...string) bool {
+	return slices.Contains(roles, c.Role)
+}
+
+// StampIdentity overwrites the identity fields of an incoming event with the
+// id authenticated at upgrade time, so a client can only speak for itself.
+// Existing keys keep their original casing since some events are relayed as is.
+func (c *Client) StampIdentity(message []byte) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(message, &fields); err != nil {
+		return nil, err
+	}
+
+	id := json.RawMessage(strconv.FormatUint(c.Id, 10))
+	hasUserId := false
+	for key := range fields {
+		if strings.EqualFold(key, "userId") {
+			fields[key] = id
+			hasUserId = true
+		} else if strings.EqualFold(key, "senderId") {
+			fields[key] = id
+		}
+	}
+	if !hasUserId {
+		fields["userId"] = id
+	}
+	return json.Marshal(fields)
+}
@@ -1,11 +1,10 @@
 package controllers
 
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
 	"math"
-	"net/http"
+	"math/rand"
 	"sync"
 	"time"
 	"websocket/models"
@@ -50,13 +49,13 @@ type Score struct {
 
 type GameState struct {
 	Ball         Ball       `json:"ball"`
+	ExtraBalls   []Ball     `json:"extraBalls"`
 	Paddles      Paddle     `json:"paddle"`
 	Score        Score      `json:"score"`
 	IsActive     bool       `json:"isActive"`
 	Winner       uint64     `json:"winner"`
-	mutex        sync.Mutex `json:"-"`
 	IsPaused     bool       `json:"isPaused"`
-	PauseTime    time.Time  `json:"pauseTime"`
+	PauseTicks   int        `json:"pauseTicks"`
 	Player1Boost BoostState `json:"player1boost"`
 	Player2Boost BoostState `json:"player2boost"`
 	ElapsedTime  int        `json:"elapsedTime"`
@@ -78,6 +77,39 @@ type Game struct {
 	Player2 Player    `json:"player2"`
 	State   GameState `json:"state"`
 	Status  string    `json:"status"`
+	mutex   sync.Mutex
+
+	// Tournament games weigh more on the players' rating.
+	IsTournamentGame bool      `json:"-"`
+	StartedAt        time.Time `json:"-"`
+	// Boosted hits of each player, the backend grants achievements on them.
+	Boosts [2]int `json:"-"`
+	// Set when a player left, the score then says nothing of the game.
+	Forfeit bool `json:"-"`
+
+	// Everything needed to replay the game: the seed feeding every random
+	// draw and the inputs with the physics tick they applied on.
+	Rules  GameRules     `json:"-"`
+	Mode   GameMode      `json:"-"`
+	Seed   int64         `json:"-"`
+	Tick   uint64        `json:"-"`
+	Inputs []ReplayInput `json:"-"`
+	rand   *rand.Rand
+}
+
+type ReplayInput struct {
+	Tick    uint64 `json:"tick"`
+	Player  int    `json:"player"`
+	Command string `json:"command"`
+}
+
+type Replay struct {
+	Rules      GameRules     `json:"rules"`
+	Seed       int64         `json:"seed"`
+	TickRateMs int64         `json:"tickRateMs"`
+	Ticks      uint64        `json:"ticks"`
+	IsGameMode bool          `json:"isGameMode"`
+	Inputs     []ReplayInput `json:"inputs"`
 }
 
 type GameCommand struct {
@@ -93,14 +125,42 @@ const (
 	Paddle1DistanceWall = 20
 	Paddle2DistanceWall = 760
 	WinningScore        = 3
+	paddleHeight        = 120
+	paddleWidth         = 20
 	paddleSpeed         = 8.0
 	collisionToBoost    = 3
 	boostMultiplier     = 2.5
+	PointPauseTicks     = int(PointPauseTime / GameTickRate)
 )
 
+// Clock is the only place a game reads the wall time, to date the match.
+// The simulation itself counts ticks.
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
 // create instance of game and init all data
-func NewGame(player1ID uint64, player2ID uint64) *Game {
-	return &Game{
+func NewGame(player1ID uint64, player2ID uint64, rules GameRules, mode GameMode) *Game {
+	return NewSimulation(player1ID, player2ID, rules, mode, systemClock{}, time.Now().UnixNano())
+}
+
+// NewSimulation builds a game on the given clock and seed, replays and tests
+// use it to get the exact same game twice. The ball waits for the opening
+// serve, like after a point.
+func NewSimulation(player1ID uint64, player2ID uint64, rules GameRules, mode GameMode, clock Clock, seed int64) *Game {
+	g := &Game{
+		Rules:     rules,
+		Mode:      mode,
+		Seed:      seed,
+		rand:      rand.New(rand.NewSource(seed)),
+		StartedAt: clock.Now(),
+		Inputs:    []ReplayInput{},
 		Player1: Player{
 			ID:       player1ID,
 			Position: CanvasHeight / 2,
@@ -115,17 +175,17 @@ func NewGame(player1ID uint64, player2ID uint64) *Game {
 			Ball: Ball{
 				X:      CanvasWidth / 2,
 				Y:      CanvasHeight / 2,
-				DX:     BallSpeed,
+				DX:     rules.BallSpeed,
 				DY:     0,
 				Radius: 10,
 			},
 
 			Paddles: Paddle{
-				Width:    20,
-				Height:   120,
+				Width:    paddleWidth,
+				Height:   rules.PaddleHeight,
 				Speed:    PaddleSpeed,
-				Player1Y: (CanvasHeight / 2) - 120/2,
-				Player2Y: (CanvasHeight / 2) - 120/2,
+				Player1Y: (CanvasHeight - rules.PaddleHeight) / 2,
+				Player2Y: (CanvasHeight - rules.PaddleHeight) / 2,
 				Player1X: Paddle1DistanceWall,
 				Player2X: Paddle2DistanceWall,
 			},
@@ -151,243 +211,299 @@ func NewGame(player1ID uint64, player2ID uint64) *Game {
 		},
 		Status: "PREGAME",
 	}
+	g.resetBall()
+	return g
 }
 
 func (g *Game) PlayerLeaved(id uint64) {
-	g.State.mutex.Lock()
-	defer g.State.mutex.Unlock()
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
 
 	if !g.State.IsActive {
 		return
 	}
 
+	// Recorded so the replay ends the way the game did.
+	g.recordInput(GameCommand{PlayerID: id, Command: "LEAVE"})
+	g.Forfeit = true
 	if id == g.Player2.ID {
 		g.State.Winner = g.Player1.ID
-		g.State.Score.Player1 = WinningScore
+		g.State.Score.Player1 = g.Rules.WinningScore
 		g.State.Score.Player2 = 0
 		return
 	}
 	g.State.Winner = g.Player2.ID
-	g.State.Score.Player2 = WinningScore
+	g.State.Score.Player2 = g.Rules.WinningScore
 	g.State.Score.Player1 = 0
 }
 
+// Snapshot copies the state for readers outside the game routine.
+func (g *Game) Snapshot() GameState {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	return g.State
+}
+
+// Update runs one tick for the game routine and sends the result once the
+// game is won. The lock is released first, the hub must not wait on the
+// backend through it.
 func (g *Game) Update() {
-	g.State.mutex.Lock()
-	defer g.State.mutex.Unlock()
+	g.mutex.Lock()
+	finished := g.Step()
+	var result map[string]interface{}
+	if finished {
+		result = g.Result()
+	}
+	g.mutex.Unlock()
+
+	if finished {
+		sendGameResultToBackend(result)
+	}
+}
 
+// Step advances the simulation by one tick of GameTickRate and tells whether
+// that tick ended the game. It depends on nothing but the game itself, so the
+// same seed and inputs always give the same state. The caller owns the lock.
+func (g *Game) Step() bool {
 	if !g.State.IsActive {
-		return
+		return false
 	}
 
+	g.Tick++
+	g.State.ElapsedTime = int(time.Duration(g.Tick) * GameTickRate / time.Second)
+
 	if g.State.IsPaused {
-		if time.Since(g.State.PauseTime) >= PointPauseTime {
-			g.State.IsPaused = false
-		} else {
-			return
+		g.State.PauseTicks--
+		if g.State.PauseTicks > 0 {
+			return false
 		}
-	}
-
-	now := time.Now()
-	if now.Sub(g.State.PauseTime) >= time.Second {
-		g.State.PauseTime = now
-		g.State.ElapsedTime++
+		g.State.IsPaused = false
 	}
 
 	// Update paddles
 	if g.State.Paddles.Player1Direction != 0 {
-		newY := g.State.Paddles.Player1Y + float64(g.State.Paddles.Player1Direction)*paddleSpeed
+		newY := g.State.Paddles.Player1Y + float64(g.State.Paddles.Player1Direction)*g.Rules.PaddleSpeed
 		g.State.Paddles.Player1Y = math.Max(0, math.Min(CanvasHeight-g.State.Paddles.Height, newY))
 	}
 
 	if g.State.Paddles.Player2Direction != 0 {
-		newY := g.State.Paddles.Player2Y + float64(g.State.Paddles.Player2Direction)*paddleSpeed
+		newY := g.State.Paddles.Player2Y + float64(g.State.Paddles.Player2Direction)*g.Rules.PaddleSpeed
 		g.State.Paddles.Player2Y = math.Max(0, math.Min(CanvasHeight-g.State.Paddles.Height, newY))
 	}
 
+	g.moveBall(&g.State.Ball)
+	for i := range g.State.ExtraBalls {
+		g.moveBall(&g.State.ExtraBalls[i])
+	}
+	g.Mode.OnTick(g)
+
+	// Score points, the first ball out ends the rally
+	for _, ball := range append([]Ball{g.State.Ball}, g.State.ExtraBalls...) {
+		if ball.X <= 0 {
+			g.scorePoint(2)
+			break
+		}
+		if ball.X >= CanvasWidth {
+			g.scorePoint(1)
+			break
+		}
+	}
+
+	// Check for winner
+	if g.State.Score.Player1 >= g.Rules.WinningScore {
+		g.State.IsActive = false
+		g.State.Winner = g.Player1.ID
+		return true
+	}
+
+	if g.State.Score.Player2 >= g.Rules.WinningScore {
+		g.State.IsActive = false
+		g.State.Winner = g.Player2.ID
+		return true
+	}
+	return false
+}
+
+// moveBall moves one ball and bounces it on the walls and paddles.
+func (g *Game) moveBall(ball *Ball) {
 	// Update ball position
-	g.State.Ball.X += g.State.Ball.DX
-	g.State.Ball.Y += g.State.Ball.DY
+	ball.X += ball.DX
+	ball.Y += ball.DY
 
 	// Ball collision with top and bottom walls
-	if g.State.Ball.Y-g.State.Ball.Radius <= 0 || g.State.Ball.Y+g.State.Ball.Radius >= CanvasHeight {
-		g.State.Ball.DY = -g.State.Ball.DY
+	if ball.Y-ball.Radius <= 0 || ball.Y+ball.Radius >= CanvasHeight {
+		ball.DY = -ball.DY
 	}
 
 	// Classic ball collision with paddle 1
-	if g.State.Ball.X <= Paddle1DistanceWall+g.State.Paddles.Width+g.State.Ball.Radius {
-		if g.State.Ball.Y >= g.State.Paddles.Player1Y &&
-			g.State.Ball.Y <= g.State.Paddles.Player1Y+g.State.Paddles.Height &&
-			g.State.Ball.X-g.State.Ball.Radius > g.State.Paddles.Player1X {
+	if ball.X <= Paddle1DistanceWall+g.State.Paddles.Width+ball.Radius {
+		if ball.Y >= g.State.Paddles.Player1Y &&
+			ball.Y <= g.State.Paddles.Player1Y+g.State.Paddles.Height &&
+			ball.X-ball.Radius > g.State.Paddles.Player1X {
 
 			multiplier := 1.0
 			if g.State.Player1Boost.IsBoostActive {
-				multiplier = boostMultiplier
+				multiplier = g.Rules.BoostMultiplier
+				g.Boosts[0]++
 				g.State.Player1Boost.IsBoostActive = false
 				g.State.Player1Boost.BoostReady = false
 			}
 
-			g.State.Ball.DX = BallSpeed * multiplier
-			g.State.Ball.DY = computeDeviation(
-				g.State.Ball.Y,
+			ball.DX = g.Rules.BallSpeed * multiplier
+			ball.DY = computeDeviation(
+				ball.Y,
 				g.State.Paddles.Player1Y,
 				g.State.Paddles.Height,
+				g.Rules.BallSpeed,
 			) * multiplier
 			g.hitCounter(1)
+			g.Mode.OnPaddleHit(g, ball)
 		}
 	}
 
 	// Classic ball collision with paddle 2
-	if g.State.Ball.X >= Paddle2DistanceWall-g.State.Ball.Radius {
-		if g.State.Ball.Y >= g.State.Paddles.Player2Y &&
-			g.State.Ball.Y <= g.State.Paddles.Player2Y+g.State.Paddles.Height &&
-			g.State.Ball.X+g.State.Ball.Radius < g.State.Paddles.Player2X+g.State.Paddles.Width {
+	if ball.X >= Paddle2DistanceWall-ball.Radius {
+		if ball.Y >= g.State.Paddles.Player2Y &&
+			ball.Y <= g.State.Paddles.Player2Y+g.State.Paddles.Height &&
+			ball.X+ball.Radius < g.State.Paddles.Player2X+g.State.Paddles.Width {
 
 			multiplier := 1.0
 			if g.State.Player2Boost.IsBoostActive {
-				multiplier = boostMultiplier
+				multiplier = g.Rules.BoostMultiplier
+				g.Boosts[1]++
 				g.State.Player2Boost.IsBoostActive = false
 				g.State.Player2Boost.BoostReady = false
 			}
-			g.State.Ball.DX = -BallSpeed * multiplier // Negative because ball should go left
-			g.State.Ball.DY = computeDeviation(
-				g.State.Ball.Y,
+			ball.DX = -g.Rules.BallSpeed * multiplier // Negative because ball should go left
+			ball.DY = computeDeviation(
+				ball.Y,
 				g.State.Paddles.Player2Y,
 				g.State.Paddles.Height,
+				g.Rules.BallSpeed,
 			) * multiplier
 			g.hitCounter(2)
+			g.Mode.OnPaddleHit(g, ball)
 		}
 	}
 	//Top part of the paddle collision
-	if g.isBallAbovePaddle() {
-		if g.State.Ball.X+g.State.Ball.Radius >= g.State.Paddles.Player1X &&
-			g.State.Ball.X-g.State.Ball.Radius <= g.State.Paddles.Player1X+g.State.Paddles.Width {
+	if g.isBallAbovePaddle(ball) {
+		if ball.X+ball.Radius >= g.State.Paddles.Player1X &&
+			ball.X-ball.Radius <= g.State.Paddles.Player1X+g.State.Paddles.Width {
 
 			// Calculate vertical distance between ball and paddle top edge
-			distanceY := math.Abs(g.State.Ball.Y - g.State.Paddles.Player1Y)
+			distanceY := math.Abs(ball.Y - g.State.Paddles.Player1Y)
 
 			// If distance is less than ball radius, we have a collision
-			if distanceY-5 <= g.State.Ball.Radius {
-				overlap := g.State.Ball.Radius - distanceY
-				g.State.Ball.DY = g.State.Ball.Y - overlap - 1
-				g.State.Ball.DX = computeSideDeviation(
-					g.State.Ball.X,
+			if distanceY-5 <= ball.Radius {
+				overlap := ball.Radius - distanceY
+				ball.DY = ball.Y - overlap - 1
+				ball.DX = computeSideDeviation(
+					ball.X,
 					g.State.Paddles.Player1X,
 					g.State.Paddles.Width,
+					g.Rules.BallSpeed,
 				)
-				g.State.Ball.DY = -BallSpeed
+				ball.DY = -g.Rules.BallSpeed
 
 			}
 		}
 	}
 
-	if g.isBallBelowPaddle() {
-		if g.State.Ball.X+g.State.Ball.Radius >= g.State.Paddles.Player1X &&
-			g.State.Ball.X-g.State.Ball.Radius <= g.State.Paddles.Player1X+g.State.Paddles.Width {
+	if g.isBallBelowPaddle(ball) {
+		if ball.X+ball.Radius >= g.State.Paddles.Player1X &&
+			ball.X-ball.Radius <= g.State.Paddles.Player1X+g.State.Paddles.Width {
 			// Calculate vertical distance between ball and paddle bottom edge
-			distanceY := math.Abs(g.State.Ball.Y - (g.State.Paddles.Player1Y + g.State.Paddles.Height))
+			distanceY := math.Abs(ball.Y - (g.State.Paddles.Player1Y + g.State.Paddles.Height))
 			// If distance is less than ball radius, we have a collision
-			if distanceY-5 <= g.State.Ball.Radius {
-				overlap := g.State.Ball.Radius - distanceY
-				g.State.Ball.DY = g.State.Ball.Y + overlap + 1
-				g.State.Ball.DX = computeSideDeviation(
-					g.State.Ball.X,
+			if distanceY-5 <= ball.Radius {
+				overlap := ball.Radius - distanceY
+				ball.DY = ball.Y + overlap + 1
+				ball.DX = computeSideDeviation(
+					ball.X,
 					g.State.Paddles.Player1X,
 					g.State.Paddles.Width,
+					g.Rules.BallSpeed,
 				)
-				g.State.Ball.DY = BallSpeed
+				ball.DY = g.Rules.BallSpeed
 
 			}
 		}
 	}
 
 	//Top part of the paddle collision for Player 2
-	if g.isBallAbovePaddle() {
-		if g.State.Ball.X+g.State.Ball.Radius >= g.State.Paddles.Player2X &&
-			g.State.Ball.X-g.State.Ball.Radius <= g.State.Paddles.Player2X+g.State.Paddles.Width {
+	if g.isBallAbovePaddle(ball) {
+		if ball.X+ball.Radius >= g.State.Paddles.Player2X &&
+			ball.X-ball.Radius <= g.State.Paddles.Player2X+g.State.Paddles.Width {
 			// Calculate vertical distance between ball and paddle top edge
-			distanceY := math.Abs(g.State.Ball.Y - g.State.Paddles.Player2Y)
+			distanceY := math.Abs(ball.Y - g.State.Paddles.Player2Y)
 			// If distance is less than ball radius, we have a collision
-			if distanceY-5 <= g.State.Ball.Radius {
-				overlap := g.State.Ball.Radius - distanceY
-				g.State.Ball.DY = g.State.Ball.Y - overlap - 1
-				g.State.Ball.DX = computeSideDeviation(
-					g.State.Ball.X,
+			if distanceY-5 <= ball.Radius {
+				overlap := ball.Radius - distanceY
+				ball.DY = ball.Y - overlap - 1
+				ball.DX = computeSideDeviation(
+					ball.X,
 					g.State.Paddles.Player2X,
 					g.State.Paddles.Width,
+					g.Rules.BallSpeed,
 				)
-				g.State.Ball.DY = -BallSpeed
+				ball.DY = -g.Rules.BallSpeed
 
 			}
 		}
 	}
-	if g.isBallBelowPaddle() {
-		if g.State.Ball.X+g.State.Ball.Radius >= g.State.Paddles.Player2X &&
-			g.State.Ball.X-g.State.Ball.Radius <= g.State.Paddles.Player2X+g.State.Paddles.Width {
+	if g.isBallBelowPaddle(ball) {
+		if ball.X+ball.Radius >= g.State.Paddles.Player2X &&
+			ball.X-ball.Radius <= g.State.Paddles.Player2X+g.State.Paddles.Width {
 			// Calculate vertical distance between ball and paddle bottom edge
-			distanceY := math.Abs(g.State.Ball.Y - (g.State.Paddles.Player2Y + g.State.Paddles.Height))
+			distanceY := math.Abs(ball.Y - (g.State.Paddles.Player2Y + g.State.Paddles.Height))
 			// If distance is less than ball radius, we have a collision
-			if distanceY-5 <= g.State.Ball.Radius {
-				overlap := g.State.Ball.Radius - distanceY
-				g.State.Ball.DY = g.State.Ball.Y + overlap + 1
-				g.State.Ball.DX = computeSideDeviation(
-					g.State.Ball.X,
+			if distanceY-5 <= ball.Radius {
+				overlap := ball.Radius - distanceY
+				ball.DY = ball.Y + overlap + 1
+				ball.DX = computeSideDeviation(
+					ball.X,
 					g.State.Paddles.Player2X,
 					g.State.Paddles.Width,
+					g.Rules.BallSpeed,
 				)
-				g.State.Ball.DY = BallSpeed
+				ball.DY = g.Rules.BallSpeed
 
 			}
 		}
 	}
+}
 
-	// Score points
-	if g.State.Ball.X <= 0 {
-		g.State.Score.Player2++
-		g.resetBall()
-		g.State.Ball.DX = -BallSpeed
-		g.resetPaddle()
-	}
-
-	if g.State.Ball.X >= CanvasWidth {
+func (g *Game) scorePoint(player int) {
+	if player == 1 {
 		g.State.Score.Player1++
 		g.resetBall()
-		g.State.Ball.DX = BallSpeed
-		g.resetPaddle()
-
-	}
-
-	// Check for winner
-	if g.State.Score.Player1 == WinningScore {
-		g.State.IsActive = false
-		g.State.Winner = g.Player1.ID
-		g.sendGameResultToBackend()
-	}
-
-	if g.State.Score.Player2 == WinningScore {
-		g.State.IsActive = false
-		g.State.Winner = g.Player2.ID
-		g.sendGameResultToBackend()
+		g.State.Ball.DX = g.Rules.BallSpeed
+	} else {
+		g.State.Score.Player2++
+		g.resetBall()
+		g.State.Ball.DX = -g.Rules.BallSpeed
 	}
+	g.resetPaddle()
+	g.Mode.OnPoint(g)
 }
 
-func (g *Game) isBallAbovePaddle() bool {
-	if g.State.Ball.Y+g.State.Ball.Radius <= g.State.Paddles.Player1Y {
+func (g *Game) isBallAbovePaddle(ball *Ball) bool {
+	if ball.Y+ball.Radius <= g.State.Paddles.Player1Y {
 		return true
 	} else {
 		return false
 	}
 }
 
-func (g *Game) isBallBelowPaddle() bool {
-	if g.State.Ball.Y-g.State.Ball.Radius >= g.State.Paddles.Player1Y+g.State.Paddles.Height {
+func (g *Game) isBallBelowPaddle(ball *Ball) bool {
+	if ball.Y-ball.Radius >= g.State.Paddles.Player1Y+g.State.Paddles.Height {
 		return true
 	} else {
 		return false
 	}
 }
 
-func computeDeviation(ballY, paddleY, paddleHeight float64) float64 {
+func computeDeviation(ballY, paddleY, paddleHeight, ballSpeed float64) float64 {
 	// trouve la position du milieu du paddel
 	midPaddle := paddleY + (paddleHeight / 2)
 	// calcule la distance du milieu du paddel a la balle
@@ -395,11 +511,11 @@ func computeDeviation(ballY, paddleY, paddleHeight float64) float64 {
 	// calcule l angle qui est entre 1 et -1
 	bounceAngle := middleDistance / (paddleHeight / 2)
 	//calcule la vitesse vertival final
-	verticalSpeed := -bounceAngle * (BallSpeed / 2)
+	verticalSpeed := -bounceAngle * (ballSpeed / 2)
 	return verticalSpeed
 }
 
-func computeSideDeviation(ballX, paddleX, paddleWidth float64) float64 {
+func computeSideDeviation(ballX, paddleX, paddleWidth, ballSpeed float64) float64 {
 	// trouve la position du milieu du paddle horizontalement
 	midPaddle := paddleX + (paddleWidth / 2)
 	// calcule la distance du milieu du paddle à la balle horizontalement
@@ -407,7 +523,7 @@ func computeSideDeviation(ballX, paddleX, paddleWidth float64) float64 {
 	// calcule l'angle qui est entre 1 et -1
 	bounceAngle := middleDistance / (paddleWidth / 2)
 	// calcule la vitesse horizontale finale
-	horizontalSpeed := -bounceAngle * (BallSpeed / 2)
+	horizontalSpeed := -bounceAngle * (ballSpeed / 2)
 	return horizontalSpeed
 }
 
@@ -416,7 +532,7 @@ func (g *Game) resetBall() {
 	g.State.Ball.Y = CanvasHeight / 2
 	g.State.Ball.DY = 0
 	g.State.IsPaused = true
-	g.State.PauseTime = time.Now()
+	g.State.PauseTicks = PointPauseTicks
 	g.State.Player1Boost.BallHit = 0
 	g.State.Player1Boost.BoostReady = false
 	g.State.Player1Boost.IsBoostActive = false
@@ -425,29 +541,32 @@ func (g *Game) resetBall() {
 	g.State.Player2Boost.IsBoostActive = false
 
 	if g.State.Ball.DX > 0 {
-		g.State.Ball.DX = -BallSpeed
+		g.State.Ball.DX = -g.Rules.BallSpeed
 	} else {
-		g.State.Ball.DX = BallSpeed
+		g.State.Ball.DX = g.Rules.BallSpeed
 	}
 }
 
 func (g *Game) resetPaddle() {
-	g.State.Paddles.Player1Y = (CanvasHeight / 2) - 120/2
-	g.State.Paddles.Player2Y = (CanvasHeight / 2) - 120/2
+	g.State.Paddles.Player1Y = (CanvasHeight - g.Rules.PaddleHeight) / 2
+	g.State.Paddles.Player2Y = (CanvasHeight - g.Rules.PaddleHeight) / 2
 	g.State.Paddles.Player1Direction = 0
 	g.State.Paddles.Player2Direction = 0
 }
 
 func (g *Game) hitCounter(playerNum int) {
+	if !g.Mode.BoostsEnabled() {
+		return
+	}
 	if playerNum == 1 {
 		g.State.Player1Boost.BallHit++
-		if g.State.Player1Boost.BallHit >= collisionToBoost {
+		if g.State.Player1Boost.BallHit >= g.Rules.CollisionToBoost {
 			g.State.Player1Boost.BoostReady = true
 			g.State.Player1Boost.BallHit = 0
 		}
 	} else {
 		g.State.Player2Boost.BallHit++
-		if g.State.Player2Boost.BallHit >= collisionToBoost {
+		if g.State.Player2Boost.BallHit >= g.Rules.CollisionToBoost {
 			g.State.Player2Boost.BoostReady = true
 			g.State.Player2Boost.BallHit = 0
 		}
@@ -473,11 +592,12 @@ func handleGameMessage(h *Hub, data []byte) {
 }
 
 func (g *Game) HandleCommand(cmd GameCommand) {
-	g.State.mutex.Lock()
-	defer g.State.mutex.Unlock()
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
 	if g.State.IsPaused {
 		return
 	}
+	g.recordInput(cmd)
 
 	switch cmd.Command {
 	case "UP":
@@ -509,33 +629,59 @@ func (g *Game) HandleCommand(cmd GameCommand) {
 	}
 }
 
-func (g *Game) sendGameResultToBackend() {
-	gameResult := map[string]interface{}{
+// recordInput keeps the commands of the players, applied before the next
+// physics tick.
+func (g *Game) recordInput(cmd GameCommand) {
+	player := 0
+	if cmd.PlayerID == g.Player1.ID {
+		player = 1
+	} else if cmd.PlayerID == g.Player2.ID {
+		player = 2
+	}
+	if player == 0 {
+		return
+	}
+	g.Inputs = append(g.Inputs, ReplayInput{
+		Tick:    g.Tick,
+		Player:  player,
+		Command: cmd.Command,
+	})
+}
+
+func (g *Game) Replay() Replay {
+	return Replay{
+		Rules:      g.Rules,
+		Seed:       g.Seed,
+		TickRateMs: GameTickRate.Milliseconds(),
+		Ticks:      g.Tick,
+		IsGameMode: g.Mode.Name() == SpecialModeName,
+		Inputs:     g.Inputs,
+	}
+}
+
+// Result is what the backend stores of a finished game. The caller owns the
+// lock.
+func (g *Game) Result() map[string]interface{} {
+	return map[string]interface{}{
 		"player1_id": g.Player1.ID,
 		"player2_id": g.Player2.ID,
 		"winner_id":  g.State.Winner,
 		"Score1":     g.State.Score.Player1,
 		"Score2":     g.State.Score.Player2,
-	}
 
-	client := &http.Client{}
-	jsonData, err := json.Marshal(gameResult)
-	fmt.Printf("%s\n", string(jsonData))
-	if err != nil {
-		fmt.Printf("Error marshalling game result: %v\n", err)
-		return
-	}
-
-	fmt.Printf("Sending game result to backend: %+v\n", gameResult)
-
-	req, err := http.NewRequest("POST", "http://backend:4000/api/game-history", bytes.NewBuffer(jsonData))
-	if err != nil {
-		fmt.Printf("Error creating request: %v\n", err)
-		return
+		"is_tournament_game": g.IsTournamentGame,
+		"is_game_mode":       g.Mode.Name() == SpecialModeName,
+		"duration":           (time.Duration(g.Tick) * GameTickRate).Seconds(),
+		"player1_boosts":     g.Boosts[0],
+		"player2_boosts":     g.Boosts[1],
+		"replay":             g.Replay(),
+		"rules":              g.Rules,
+		"forfeit":            g.Forfeit,
 	}
+}
 
-	req.Header.Set("Content-Type", "application/json")
-	resp, err := client.Do(req)
+func sendGameResultToBackend(gameResult map[string]interface{}) {
+	resp, err := RequestBackend("POST", "/game-history", gameResult)
 	if err != nil {
 		fmt.Printf("Error sending game result: %v\n", err)
 		return
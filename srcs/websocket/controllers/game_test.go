@@ -0,0 +1,343 @@
+package controllers
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+)
+
+const (
+	testPlayer1 uint64 = 1
+	testPlayer2 uint64 = 2
+	testSeed    int64  = 42
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c fakeClock) Now() time.Time {
+	return c.now
+}
+
+func newTestGame(mode GameMode) *Game {
+	return NewSimulation(testPlayer1, testPlayer2, DefaultGameRules(), mode, fakeClock{now: time.Unix(0, 0)}, testSeed)
+}
+
+// newRallyGame is a game past the opening pause, with the ball placed by the
+// test.
+func newRallyGame(x, y, dx float64) *Game {
+	g := newTestGame(ClassicMode{})
+	g.State.IsPaused = false
+	g.State.PauseTicks = 0
+	g.State.Ball.X = x
+	g.State.Ball.Y = y
+	g.State.Ball.DX = dx
+	g.State.Ball.DY = 0
+	return g
+}
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestServeDirection(t *testing.T) {
+	tests := []struct {
+		name   string
+		setup  func(g *Game)
+		wantDX float64
+	}{
+		{
+			name:   "opening serve goes to player 1",
+			setup:  func(g *Game) {},
+			wantDX: -BallSpeed,
+		},
+		{
+			name:   "player 1 scored, serve goes to player 2",
+			setup:  func(g *Game) { g.scorePoint(1) },
+			wantDX: BallSpeed,
+		},
+		{
+			name:   "player 2 scored, serve goes to player 1",
+			setup:  func(g *Game) { g.scorePoint(2) },
+			wantDX: -BallSpeed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newTestGame(ClassicMode{})
+			tt.setup(g)
+
+			ball := g.State.Ball
+			if ball.X != CanvasWidth/2 || ball.Y != CanvasHeight/2 {
+				t.Errorf("ball at (%v, %v), want the center", ball.X, ball.Y)
+			}
+			if ball.DX != tt.wantDX || ball.DY != 0 {
+				t.Errorf("ball speed (%v, %v), want (%v, 0)", ball.DX, ball.DY, tt.wantDX)
+			}
+			if !g.State.IsPaused || g.State.PauseTicks != PointPauseTicks {
+				t.Errorf("paused %v for %d ticks, want %d ticks", g.State.IsPaused, g.State.PauseTicks, PointPauseTicks)
+			}
+		})
+	}
+}
+
+func TestPaddleBounce(t *testing.T) {
+	// Both paddles start centered, from y 240 to 360.
+	tests := []struct {
+		name   string
+		x, y   float64
+		dx     float64
+		wantDX float64
+		wantDY float64
+	}{
+		{name: "paddle 1 center", x: 55, y: 300, dx: -BallSpeed, wantDX: BallSpeed, wantDY: 0},
+		{name: "paddle 1 upper half", x: 55, y: 270, dx: -BallSpeed, wantDX: BallSpeed, wantDY: -BallSpeed / 4},
+		{name: "paddle 2 center", x: 745, y: 300, dx: BallSpeed, wantDX: -BallSpeed, wantDY: 0},
+		{name: "paddle 2 lower half", x: 745, y: 330, dx: BallSpeed, wantDX: -BallSpeed, wantDY: BallSpeed / 4},
+		{name: "ball above paddle 1 passes", x: 55, y: 100, dx: -BallSpeed, wantDX: -BallSpeed, wantDY: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newRallyGame(tt.x, tt.y, tt.dx)
+			g.Step()
+
+			ball := g.State.Ball
+			if !almostEqual(ball.DX, tt.wantDX) || !almostEqual(ball.DY, tt.wantDY) {
+				t.Errorf("ball speed (%v, %v), want (%v, %v)", ball.DX, ball.DY, tt.wantDX, tt.wantDY)
+			}
+		})
+	}
+}
+
+func TestScoring(t *testing.T) {
+	tests := []struct {
+		name      string
+		x, dx     float64
+		wantScore Score
+		wantDX    float64
+	}{
+		{name: "ball out on the left", x: 5, dx: -BallSpeed, wantScore: Score{Player1: 0, Player2: 1}, wantDX: -BallSpeed},
+		{name: "ball out on the right", x: 795, dx: BallSpeed, wantScore: Score{Player1: 1, Player2: 0}, wantDX: BallSpeed},
+		{name: "ball still in play", x: 400, dx: BallSpeed, wantScore: Score{}, wantDX: BallSpeed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newRallyGame(tt.x, 100, tt.dx)
+			g.Step()
+
+			if g.State.Score != tt.wantScore {
+				t.Errorf("score %+v, want %+v", g.State.Score, tt.wantScore)
+			}
+			if g.State.Ball.DX != tt.wantDX {
+				t.Errorf("ball DX %v, want %v", g.State.Ball.DX, tt.wantDX)
+			}
+			scored := tt.wantScore != Score{}
+			if g.State.IsPaused != scored {
+				t.Errorf("paused %v, want %v", g.State.IsPaused, scored)
+			}
+		})
+	}
+}
+
+func TestPauseAfterPoint(t *testing.T) {
+	tests := []struct {
+		name       string
+		steps      int
+		wantX      float64
+		wantPaused bool
+	}{
+		{name: "ball waits during the pause", steps: PointPauseTicks - 1, wantX: CanvasWidth / 2, wantPaused: true},
+		{name: "ball moves on the last pause tick", steps: PointPauseTicks, wantX: CanvasWidth/2 + BallSpeed, wantPaused: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newRallyGame(795, 100, BallSpeed)
+			g.Step()
+			if g.State.Score.Player1 != 1 {
+				t.Fatalf("score %+v, want a point for player 1", g.State.Score)
+			}
+
+			for i := 0; i < tt.steps; i++ {
+				g.Step()
+			}
+			if g.State.Ball.X != tt.wantX || g.State.IsPaused != tt.wantPaused {
+				t.Errorf("ball x %v paused %v, want x %v paused %v", g.State.Ball.X, g.State.IsPaused, tt.wantX, tt.wantPaused)
+			}
+		})
+	}
+
+	t.Run("commands are ignored during the pause", func(t *testing.T) {
+		g := newTestGame(ClassicMode{})
+		g.HandleCommand(GameCommand{PlayerID: testPlayer1, Command: "UP"})
+		if g.State.Paddles.Player1Direction != 0 || len(g.Inputs) != 0 {
+			t.Errorf("direction %d with %d inputs, want the command ignored", g.State.Paddles.Player1Direction, len(g.Inputs))
+		}
+	})
+}
+
+func TestWinCheck(t *testing.T) {
+	tests := []struct {
+		name        string
+		setup       func(g *Game)
+		x, dx       float64
+		wantDone    bool
+		wantWinner  uint64
+		wantForfeit bool
+	}{
+		{
+			name:       "player 1 reaches the winning score",
+			setup:      func(g *Game) { g.State.Score.Player1 = g.Rules.WinningScore - 1 },
+			x:          795,
+			dx:         BallSpeed,
+			wantDone:   true,
+			wantWinner: testPlayer1,
+		},
+		{
+			name:       "player 2 reaches the winning score",
+			setup:      func(g *Game) { g.State.Score.Player2 = g.Rules.WinningScore - 1 },
+			x:          5,
+			dx:         -BallSpeed,
+			wantDone:   true,
+			wantWinner: testPlayer2,
+		},
+		{
+			name:     "a point below the winning score goes on",
+			setup:    func(g *Game) {},
+			x:        795,
+			dx:       BallSpeed,
+			wantDone: false,
+		},
+		{
+			name:        "player 2 leaves",
+			setup:       func(g *Game) { g.PlayerLeaved(testPlayer2) },
+			x:           400,
+			dx:          BallSpeed,
+			wantDone:    true,
+			wantWinner:  testPlayer1,
+			wantForfeit: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newRallyGame(tt.x, 100, tt.dx)
+			tt.setup(g)
+
+			done := g.Step()
+			if done != tt.wantDone || g.State.IsActive == tt.wantDone {
+				t.Fatalf("done %v active %v, want done %v", done, g.State.IsActive, tt.wantDone)
+			}
+			if done && g.State.Winner != tt.wantWinner {
+				t.Errorf("winner %d, want %d", g.State.Winner, tt.wantWinner)
+			}
+			if g.Forfeit != tt.wantForfeit {
+				t.Errorf("forfeit %v, want %v", g.Forfeit, tt.wantForfeit)
+			}
+			if g.Step() {
+				t.Errorf("a finished game ended again")
+			}
+		})
+	}
+}
+
+// playerDirection is what a player tracking the ball presses. Aiming off the
+// paddle center angles the ball, lazy players only look at the ball every
+// few ticks so that rallies end.
+func playerDirection(g *Game, paddleY float64, aim float64, lazy bool) int {
+	if lazy && g.Tick%5 != 0 {
+		return 0
+	}
+	target := paddleY + g.State.Paddles.Height/2 + aim
+	switch {
+	case g.State.Ball.Y < target-10:
+		return -1
+	case g.State.Ball.Y > target+10:
+		return 1
+	}
+	return 0
+}
+
+var directionCommands = map[int]string{-1: "UP", 0: "STOP", 1: "DOWN"}
+
+// playGame runs a game between a tracking player 1 and a lazy player 2, who
+// leaves at leaveTick when it is set.
+func playGame(g *Game, maxTicks uint64, leaveTick uint64) {
+	for g.Tick < maxTicks && g.State.IsActive {
+		if leaveTick != 0 && g.Tick == leaveTick {
+			g.PlayerLeaved(testPlayer2)
+		}
+		if direction := playerDirection(g, g.State.Paddles.Player1Y, 25, false); direction != g.State.Paddles.Player1Direction {
+			g.HandleCommand(GameCommand{PlayerID: testPlayer1, Command: directionCommands[direction]})
+		}
+		if direction := playerDirection(g, g.State.Paddles.Player2Y, -25, true); direction != g.State.Paddles.Player2Direction {
+			g.HandleCommand(GameCommand{PlayerID: testPlayer2, Command: directionCommands[direction]})
+		}
+		if g.State.Player1Boost.BoostReady {
+			g.HandleCommand(GameCommand{PlayerID: testPlayer1, Command: "SPACE"})
+		}
+		g.Step()
+	}
+}
+
+// replayGame re-simulates a replay, each input applied before the tick it
+// was recorded on.
+func replayGame(replay Replay) *Game {
+	g := NewSimulation(testPlayer1, testPlayer2, replay.Rules, NewGameMode(replay.IsGameMode), fakeClock{now: time.Unix(0, 0)}, replay.Seed)
+	players := map[int]uint64{1: testPlayer1, 2: testPlayer2}
+
+	next := 0
+	for g.Tick < replay.Ticks {
+		for ; next < len(replay.Inputs) && replay.Inputs[next].Tick == g.Tick; next++ {
+			input := replay.Inputs[next]
+			if input.Command == "LEAVE" {
+				g.PlayerLeaved(players[input.Player])
+				continue
+			}
+			g.HandleCommand(GameCommand{PlayerID: players[input.Player], Command: input.Command})
+		}
+		g.Step()
+	}
+	return g
+}
+
+func TestReplayMatchesGame(t *testing.T) {
+	tests := []struct {
+		name      string
+		mode      GameMode
+		leaveTick uint64
+	}{
+		{name: "classic", mode: ClassicMode{}},
+		{name: "special", mode: &SpecialMode{}},
+		{name: "forfeit", mode: &SpecialMode{}, leaveTick: 1500},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := newTestGame(tt.mode)
+			playGame(g, 20000, tt.leaveTick)
+			if len(g.Inputs) == 0 {
+				t.Fatalf("no input was recorded")
+			}
+			if g.Forfeit != (tt.leaveTick != 0) {
+				t.Fatalf("forfeit %v, want %v", g.Forfeit, tt.leaveTick != 0)
+			}
+
+			replayed := replayGame(g.Replay())
+			if replayed.Tick != g.Tick {
+				t.Errorf("replay ran %d ticks, want %d", replayed.Tick, g.Tick)
+			}
+			if !reflect.DeepEqual(replayed.State, g.State) {
+				t.Errorf("replay ended on\n%+v\nwant\n%+v", replayed.State, g.State)
+			}
+			if replayed.Forfeit != g.Forfeit {
+				t.Errorf("replay forfeit %v, want %v", replayed.Forfeit, g.Forfeit)
+			}
+		})
+	}
+}
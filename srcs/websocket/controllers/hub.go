@@ -11,25 +11,81 @@ import (
 )
 
 type Hub struct {
-	Clients     map[uint64]*Client
-	Broadcast   chan []byte
-	Register    chan *Client
-	Unregister  chan *Client
-	Lobbies     map[uuid.UUID]*Lobby
-	Tournaments map[string]*Tournament
+	Clients         map[uint64]*Client
+	Broadcast       chan []byte
+	Dispatch        chan *Dispatch
+	Callbacks       chan func()
+	Register        chan *Client
+	Unregister      chan *Client
+	Lobbies         map[uuid.UUID]*Lobby
+	Invitations     map[uuid.UUID]*LobbyInvite
+	Tournaments     map[string]*Tournament
+	Typing          map[uint64]*TypingState
+	Matchmaking     []*MatchmakingEntry
+	Relations       map[relationKey]*cachedRelation
+	RelationFetches map[relationKey][]func(*Relation, error)
+	Queues          map[uint64][]func() func()
 }
 
 func NewHub() *Hub {
 	return &Hub{
-		Clients:     make(map[uint64]*Client),
-		Broadcast:   make(chan []byte),
-		Register:    make(chan *Client),
-		Unregister:  make(chan *Client),
-		Lobbies:     make(map[uuid.UUID]*Lobby),
-		Tournaments: make(map[string]*Tournament),
+		Clients:         make(map[uint64]*Client),
+		Broadcast:       make(chan []byte),
+		Dispatch:        make(chan *Dispatch),
+		Callbacks:       make(chan func()),
+		Register:        make(chan *Client),
+		Unregister:      make(chan *Client),
+		Lobbies:         make(map[uuid.UUID]*Lobby),
+		Invitations:     make(map[uuid.UUID]*LobbyInvite),
+		Tournaments:     make(map[string]*Tournament),
+		Typing:          make(map[uint64]*TypingState),
+		Relations:       make(map[relationKey]*cachedRelation),
+		RelationFetches: make(map[relationKey][]func(*Relation, error)),
+		Queues:          make(map[uint64][]func() func()),
 	}
 }
 
+// Async runs work, usually a backend request, outside of the hub goroutine.
+// The function it returns is then run by the hub, it is the only place where
+// the result may touch the hub state.
+func (h *Hub) Async(work func() func()) {
+	go func() {
+		if callback := work(); callback != nil {
+			h.Callbacks <- callback
+		}
+	}()
+}
+
+// Enqueue is Async for work that must keep its order, such as the messages of
+// a same sender: the work queued under a key starts once the previous one is
+// done and its callback ran.
+func (h *Hub) Enqueue(key uint64, work func() func()) {
+	queue := h.Queues[key]
+	h.Queues[key] = append(queue, work)
+	if len(queue) == 0 {
+		h.runQueue(key)
+	}
+}
+
+func (h *Hub) runQueue(key uint64) {
+	work := h.Queues[key][0]
+	h.Async(func() func() {
+		callback := work()
+		return func() {
+			if callback != nil {
+				callback()
+			}
+			queue := h.Queues[key][1:]
+			if len(queue) == 0 {
+				delete(h.Queues, key)
+				return
+			}
+			h.Queues[key] = queue
+			h.runQueue(key)
+		}
+	})
+}
+
 func (h *Hub) GetEventType(message []byte) (models.Event, error) {
 	var evt models.Event
 	if err := json.Unmarshal(message, &evt); err != nil {
@@ -56,6 +112,12 @@ func (h *Hub) RemoveClient(client *Client) {
 		}
 	}
 
+	h.DropInvitations(client.Id)
+	h.DetachSpectator(client.Id)
+	StopTyping(h, client.Id)
+	delete(h.Typing, client.Id)
+	h.Dequeue(client.Id)
+
 	go func() {
 		time.Sleep(10 * time.Millisecond)
 		NotifyClients(h, client.Id, "USER_DISCONNECTED")
@@ -65,14 +127,37 @@ func (h *Hub) RemoveClient(client *Client) {
 }
 
 func (h *Hub) Run() {
+	matchmakingTicker := time.NewTicker(MatchmakingTick)
+	defer matchmakingTicker.Stop()
+	relationsTicker := time.NewTicker(RelationTTL)
+	defer relationsTicker.Stop()
+
 	for {
 		select {
+		case <-matchmakingTicker.C:
+			MatchPlayers(h)
+		case <-relationsTicker.C:
+			h.PruneRelations()
+		case callback := <-h.Callbacks:
+			callback()
 		case client := <-h.Register:
 			h.Clients[client.Id] = client
 			SendOnlineUsersToClient(h, client)
 			NotifyClients(h, client.Id, "NEW_CONNECTION")
+			if client.LastMessageID != nil {
+				ReplayMissedMessages(h, client, *client.LastMessageID)
+			}
 		case client := <-h.Unregister:
 			h.RemoveClient(client)
+		case dispatch := <-h.Dispatch:
+			for _, id := range dispatch.Recipients {
+				if client, ok := h.Clients[id]; ok {
+					safeSend(client.Send, dispatch.Message)
+					if dispatch.Disconnect {
+						h.RemoveClient(client)
+					}
+				}
+			}
 		case message := <-h.Broadcast:
 			event, err := h.GetEventType(message)
 			if err != nil {
@@ -82,6 +167,16 @@ func (h *Hub) Run() {
 			switch {
 			case event.Type == "CHAT":
 				HandleChatMessage(h, message)
+			case event.Type == "CHAT_READ":
+				HandleChatRead(h, message)
+			case event.Type == "CHAT_SYNC":
+				HandleChatSync(h, message)
+			case event.Type == "CHANNEL_MESSAGE":
+				HandleChannelMessage(h, message)
+			case strings.HasPrefix(event.Type, "CHAT_TYPING_"):
+				HandleTyping(h, event.Type, message)
+			case strings.HasPrefix(event.Type, "MATCHMAKING_"):
+				HandleMatchmaking(h, event.Type, message)
 			case strings.HasPrefix(event.Type, "LOBBY_"):
 				HandleLobby(h, event.Type, message)
 			case event.Type == "GAME_EVENT":
@@ -0,0 +1,55 @@
+package controllers
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"websocket/utils"
+)
+
+// Dispatch is an event pushed by the backend, the hub forwards it as is to
+// the recipients that are connected. Disconnect closes their connections
+// right after.
+type Dispatch struct {
+	Recipients []uint64
+	Message    []byte
+	Disconnect bool
+}
+
+type InternalEventRequest struct {
+	Recipients []uint64        `json:"recipients"`
+	Event      json.RawMessage `json:"event"`
+	Disconnect bool            `json:"disconnect"`
+}
+
+func ServeInternalEvent(h *Hub, w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !found || token == "" {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	if _, err := utils.DecryptServiceToken(token); err != nil {
+		log.Printf("error decoding service token: %v", err)
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+
+	var request InternalEventRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || len(request.Event) == 0 {
+		http.Error(w, "Invalid event", http.StatusBadRequest)
+		return
+	}
+
+	h.Dispatch <- &Dispatch{
+		Recipients: request.Recipients,
+		Message:    request.Event,
+		Disconnect: request.Disconnect,
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
@@ -13,6 +13,9 @@ import (
 const (
 	PointPauseTime = 1 * time.Second
 	GameTickRate   = 16 * time.Millisecond
+
+	// Given for offline, banned or blocking receivers alike.
+	LobbyInviteRefusedReason = "This player cannot be invited"
 )
 
 type LobbyTimestamps struct {
@@ -34,6 +37,16 @@ type Lobby struct {
 	IsTournamentGame bool            `json:"isTournamentGame"`
 	IsActive         bool            `json:"isActive"`
 	IsGameMode       bool            `json:"isGameMode"`
+	Rules            GameRules       `json:"rules"`
+	// Read-only watchers, see spectator.go.
+	Spectators map[uint64]*Client `json:"-"`
+}
+
+// LobbyInvite waits for the invited player's answer, an accept or a deny is
+// only taken from that player and for a lobby id the hub handed out.
+type LobbyInvite struct {
+	SenderId   uint64
+	ReceiverId uint64
 }
 
 type LobbyUserState struct {
@@ -49,6 +62,7 @@ type LobbyEvent struct {
 	Receiver         LobbyUserState `json:"receiver"`
 	IsTournamentGame bool           `json:"isTournamentGame"`
 	IsGameMode       bool           `json:"isGameMode"`
+	Rules            *GameRules     `json:"rules,omitempty"`
 }
 
 type LobbyErrorEvent struct {
@@ -60,26 +74,43 @@ type LobbyErrorEvent struct {
 func HandleLobby(h *Hub, event string, data []byte) {
 	var request LobbyEvent
 	if err := json.Unmarshal(data, &request); err != nil {
-		fmt.Printf("Impossible to parse LobbyCreationRequest type: ", err.Error())
+		fmt.Printf("Impossible to parse LobbyCreationRequest type: %v\n", err)
 		return
 	}
+	// UserId is stamped by the client pump, the side of the lobby the
+	// requester stands on depends on the event.
+	switch event {
+	case "LOBBY_INVITATION_TO_FRIEND", "LOBBY_DENY_FROM_FRIEND", "LOBBY_TERMINATE":
+		request.Sender.Id = request.UserId
+	case "LOBBY_ACCEPT_FROM_FRIEND":
+		request.Receiver.Id = request.UserId
+	}
 	switch event {
 	case "LOBBY_INVITATION_TO_FRIEND":
 		LobbyInvitation(h, request)
 	case "LOBBY_ACCEPT_FROM_FRIEND":
-		LobbyCreation(h, request)
+		LobbyAccept(h, request)
 	case "LOBBY_DENY_FROM_FRIEND":
-		LobbyDenied(h, request)
+		LobbyDeny(h, request)
 	case "LOBBY_TERMINATE":
 		LobbyTerminate(h, request)
 	case "LOBBY_GAME_LEAVE":
+		// A spectator leaving the game view only detaches itself.
+		if lobby, exists := h.Lobbies[request.LobbyId]; exists && !lobby.IsPlayer(request.UserId) {
+			HandleSpectate(h, "LOBBY_SPECTATE_LEAVE", request)
+			return
+		}
 		LobbyClientHasLeft(h, request.LobbyId, request.UserId)
 	case "LOBBY_SPECIAL_MODE_TOGGLED":
 		UpdateSpecialMode(h, request)
+	case "LOBBY_RULES_UPDATED":
+		UpdateRules(h, request)
 	case "LOBBY_PLAYER_READY_STATUS":
 		LobbyUpdatePlayerStatus(h, request)
 	case "LOBBY_PLAYER_UNREADY_STATUS":
 		LobbyUpdatePlayerStatus(h, request)
+	case "LOBBY_SPECTATE", "LOBBY_SPECTATE_LEAVE", "LOBBY_LIST_LIVE":
+		HandleSpectate(h, event, request)
 	}
 }
 
@@ -105,7 +136,7 @@ func LobbyClientHasLeft(h *Hub, lobbyId uuid.UUID, clientId uint64) {
 
 	errorJson, err := json.Marshal(&error)
 	if err != nil {
-		fmt.Printf("Impossible to parse LobbyErrorEvent type: ", err.Error())
+		fmt.Printf("Impossible to parse LobbyErrorEvent type: %v\n", err)
 		return
 	}
 
@@ -116,39 +147,144 @@ func LobbyClientHasLeft(h *Hub, lobbyId uuid.UUID, clientId uint64) {
 	if lobby.Receiver != nil {
 		safeSend(lobby.Receiver.Send, errorJson)
 	}
+	lobby.SendToSpectators(errorJson)
 
 	if lobby.Game != nil {
 		lobby.Game.PlayerLeaved(clientId)
 	}
 }
 
+// LobbyInviteRefused tells the sender the invitation went nowhere, without
+// telling whether the receiver blocked them.
+func LobbyInviteRefused(h *Hub, request LobbyEvent, reason string) {
+	sender, exists := h.Clients[request.Sender.Id]
+	if !exists {
+		return
+	}
+	refusal := LobbyErrorEvent{
+		Event: models.Event{
+			Type: "LOBBY_INVITATION_REFUSED",
+		},
+		LobbyId: request.LobbyId,
+		Error:   reason,
+	}
+	refusalJson, _ := json.Marshal(&refusal)
+	safeSend(sender.Send, refusalJson)
+}
+
+// LobbyInvitation relays the invitation once the backend confirmed neither
+// player is banned and the two did not block each other.
 func LobbyInvitation(h *Hub, request LobbyEvent) {
+	if _, exists := h.Clients[request.Receiver.Id]; !exists {
+		LobbyInviteRefused(h, request, LobbyInviteRefusedReason)
+		return
+	}
+
+	h.WhenNotBanned(request.Sender.Id, func(err error) {
+		if err != nil {
+			LobbyInviteRefused(h, request, err.Error())
+			return
+		}
+		h.WhenNotBanned(request.Receiver.Id, func(err error) {
+			if err != nil {
+				LobbyInviteRefused(h, request, LobbyInviteRefusedReason)
+				return
+			}
+			h.WithRelation(request.Sender.Id, request.Receiver.Id, func(relation *Relation, err error) {
+				if err != nil || relation.Blocked {
+					LobbyInviteRefused(h, request, LobbyInviteRefusedReason)
+					return
+				}
+				sendLobbyInvitation(h, request)
+			})
+		})
+	})
+}
+
+func sendLobbyInvitation(h *Hub, request LobbyEvent) {
+	sender, senderExists := h.Clients[request.Sender.Id]
+	receiver, receiverExists := h.Clients[request.Receiver.Id]
+	if !senderExists {
+		return
+	}
+	if !receiverExists {
+		LobbyInviteRefused(h, request, LobbyInviteRefusedReason)
+		return
+	}
+
 	lobbyId := uuid.New()
 	request.LobbyId = lobbyId
+	h.Invitations[lobbyId] = &LobbyInvite{SenderId: sender.Id, ReceiverId: receiver.Id}
 
 	senderJson, err := json.Marshal(&request)
 	if err != nil {
-		fmt.Printf("Impossible to parse LobbyEvent type: ", err.Error())
+		fmt.Printf("Impossible to parse LobbyEvent type: %v\n", err)
 		return
 	}
-	safeSend(h.Clients[request.Sender.Id].Send, senderJson)
+	safeSend(sender.Send, senderJson)
 
 	request.Type = "LOBBY_INVITATION_FROM_FRIEND"
 	receiverJson, err := json.Marshal(&request)
 	if err != nil {
-		fmt.Printf("Impossible to parse LobbyEvent type: ", err.Error())
+		fmt.Printf("Impossible to parse LobbyEvent type: %v\n", err)
 		return
 	}
-	safeSend(h.Clients[request.Receiver.Id].Send, receiverJson)
+	safeSend(receiver.Send, receiverJson)
+}
+
+// TakeInvitation removes the invitation the receiver answers to, it tells
+// whether there was one.
+func (h *Hub) TakeInvitation(lobbyId uuid.UUID, receiverId uint64) (*LobbyInvite, bool) {
+	invite, exists := h.Invitations[lobbyId]
+	if !exists || invite.ReceiverId != receiverId {
+		return nil, false
+	}
+	delete(h.Invitations, lobbyId)
+	return invite, true
+}
+
+// DropInvitations forgets the invitations sent or received by the client.
+func (h *Hub) DropInvitations(clientId uint64) {
+	for lobbyId, invite := range h.Invitations {
+		if invite.SenderId == clientId || invite.ReceiverId == clientId {
+			delete(h.Invitations, lobbyId)
+		}
+	}
+}
+
+// LobbyAccept opens the lobby of a pending invitation, the sender is the one
+// who invited and never the one the client claims.
+func LobbyAccept(h *Hub, request LobbyEvent) {
+	invite, exists := h.TakeInvitation(request.LobbyId, request.Receiver.Id)
+	if !exists {
+		fmt.Printf("No pending invitation %s for %d\n", request.LobbyId, request.Receiver.Id)
+		return
+	}
+	request.Sender.Id = invite.SenderId
+	LobbyCreation(h, request)
+}
+
+// LobbyDeny answers a pending invitation, the client sends it with itself as
+// the sender.
+func LobbyDeny(h *Hub, request LobbyEvent) {
+	invite, exists := h.TakeInvitation(request.LobbyId, request.Sender.Id)
+	if !exists {
+		fmt.Printf("No pending invitation %s for %d\n", request.LobbyId, request.Sender.Id)
+		return
+	}
+	request.Receiver.Id = invite.SenderId
+	LobbyDenied(h, request)
 }
 
 func LobbyCreation(h *Hub, request LobbyEvent) {
 	lobby, err := NewLobby(h, request)
 	if err != nil {
-		fmt.Printf("Lobby creation failed : ", err.Error())
+		fmt.Printf("Lobby creation failed : %v\n", err)
 		return
 	}
 	h.Lobbies[lobby.Id] = lobby
+	h.Dequeue(lobby.Sender.Id)
+	h.Dequeue(lobby.Receiver.Id)
 
 	response := LobbyEvent{
 		Event: models.Event{
@@ -164,10 +300,11 @@ func LobbyCreation(h *Hub, request LobbyEvent) {
 		},
 		LobbyId:          lobby.Id,
 		IsTournamentGame: false,
+		Rules:            &lobby.Rules,
 	}
 	jsonData, err := json.Marshal(&response)
 	if err != nil {
-		fmt.Printf("Impossible to parse LobbyEvent type: ", err.Error())
+		fmt.Printf("Impossible to parse LobbyEvent type: %v\n", err)
 		return
 	}
 
@@ -179,7 +316,7 @@ func LobbyDenied(h *Hub, request LobbyEvent) {
 	request.Type = "LOBBY_DENIED"
 	jsonData, err := json.Marshal(&request)
 	if err != nil {
-		fmt.Printf("Impossible to parse LobbyEvent type: ", err.Error())
+		fmt.Printf("Impossible to parse LobbyEvent type: %v\n", err)
 		return
 	}
 
@@ -196,12 +333,13 @@ func LobbyTerminate(h *Hub, request LobbyEvent) {
 	request.Type = "LOBBY_DESTROYED"
 	jsonData, err := json.Marshal(&request)
 	if err != nil {
-		fmt.Printf("Impossible to parse LobbyEvent type: ", err.Error())
+		fmt.Printf("Impossible to parse LobbyEvent type: %v\n", err)
 		return
 	}
 
 	safeSend(lobby.Sender.Send, jsonData)
 	safeSend(lobby.Receiver.Send, jsonData)
+	lobby.SendToSpectators(jsonData)
 
 	if lobby.Destroy != nil {
 		safeClose(lobby.Destroy)
@@ -226,7 +364,7 @@ func LobbyUpdatePlayerStatus(h *Hub, request LobbyEvent) {
 		isReady = true
 	}
 
-	fmt.Printf("Request: \n", request)
+	fmt.Printf("Request: %+v\n", request)
 	if request.UserId == lobby.Sender.Id {
 		lobby.PlayersReady[0] = isReady
 	} else if request.UserId == lobby.Receiver.Id {
@@ -245,13 +383,14 @@ func LobbyUpdatePlayerStatus(h *Hub, request LobbyEvent) {
 	request.Type = "LOBBY_PLAYER_STATUS"
 	jsonData, err := json.Marshal(&request)
 	if err != nil {
-		fmt.Printf("Impossible to parse LobbyEvent type: ", err.Error())
+		fmt.Printf("Impossible to parse LobbyEvent type: %v\n", err)
 		return
 	}
 
 	safeSend(lobby.Sender.Send, jsonData)
 	safeSend(lobby.Receiver.Send, jsonData)
-	if lobby.PlayersReady[0] && lobby.PlayersReady[1] {
+	if lobby.PlayersReady[0] && lobby.PlayersReady[1] && lobby.Game == nil {
+		lobby.CreateGame()
 		go func() {
 			time.Sleep(100 * time.Millisecond)
 			StartRoutine(h, lobby)
@@ -279,7 +418,7 @@ func UpdateSpecialMode(h *Hub, request LobbyEvent) {
 
 	senderJson, err := json.Marshal(&event)
 	if err != nil {
-		fmt.Printf("Impossible to parse LobbyEvent type: ", err.Error())
+		fmt.Printf("Impossible to parse LobbyEvent type: %v\n", err)
 		return
 	}
 
@@ -287,13 +426,21 @@ func UpdateSpecialMode(h *Hub, request LobbyEvent) {
 	safeSend(lobby.Receiver.Send, senderJson)
 }
 
-func StartRoutine(h *Hub, lobby *Lobby) {
+// CreateGame builds the game from the lobby settings before StartRoutine
+// runs it. Invited lobbies call it on the hub as both players get ready, so
+// the rules cannot change under a game being built.
+func (lobby *Lobby) CreateGame() {
 	lobby.Timestamps.Pregame = time.Now()
 	lobby.Destroy = make(chan struct{})
 	if lobby.IsTournamentGame {
 		lobby.IsGameMode = true
 	}
-	lobby.Game = NewGame(lobby.Sender.Id, lobby.Receiver.Id)
+	lobby.Game = NewGame(lobby.Sender.Id, lobby.Receiver.Id, lobby.Rules, NewGameMode(lobby.IsGameMode))
+	lobby.Game.IsTournamentGame = lobby.IsTournamentGame
+}
+
+// StartRoutine runs the game CreateGame built.
+func StartRoutine(h *Hub, lobby *Lobby) {
 	gameTicker := time.NewTicker(GameTickRate)
 
 	gameStart := models.Event{
@@ -302,14 +449,13 @@ func StartRoutine(h *Hub, lobby *Lobby) {
 
 	dataJson, err := json.Marshal(gameStart)
 	if err != nil {
-		fmt.Printf("Impossible to parse GameStart type: ", err.Error())
+		fmt.Printf("Impossible to parse GameStart type: %v\n", err)
 		return
 	}
 
 	safeSend(lobby.Sender.Send, dataJson)
 	safeSend(lobby.Receiver.Send, dataJson)
 
-	lobby.Game.resetBall()
 	go func() {
 		for {
 			select {
@@ -332,6 +478,7 @@ func StartRoutine(h *Hub, lobby *Lobby) {
 					stateJson, _ := json.Marshal(evt)
 					safeSend(lobby.Sender.Send, stateJson)
 					safeSend(lobby.Receiver.Send, stateJson)
+					lobby.SendToSpectators(stateJson)
 				} else if lobby.Game.State.IsActive == false && lobby.Game.State.Winner != 0 {
 					evt := GameEvent{
 						Event: models.Event{
@@ -347,6 +494,7 @@ func StartRoutine(h *Hub, lobby *Lobby) {
 					stateJson, _ := json.Marshal(evt)
 					safeSend(lobby.Sender.Send, stateJson)
 					safeSend(lobby.Receiver.Send, stateJson)
+					lobby.SendToSpectators(stateJson)
 					return
 				}
 			}
@@ -367,7 +515,7 @@ func (lobby *Lobby) DispatchTimer(timeLeft time.Duration) {
 
 	jsonData, err := json.Marshal(&RemainingTime)
 	if err != nil {
-		fmt.Printf("Impossible to parse RemainingTime type: ", err.Error())
+		fmt.Printf("Impossible to parse RemainingTime type: %v\n", err)
 		return
 	}
 	safeSend(lobby.Sender.Send, jsonData)
@@ -391,6 +539,7 @@ func NewLobby(h *Hub, request LobbyEvent) (*Lobby, error) {
 		Timestamps:   LobbyTimestamps{},
 		Status:       "LOBBY_CREATION",
 		PlayersReady: [2]bool{false, false},
+		Rules:        DefaultGameRules(),
 	}
 	return newSession, nil
 }
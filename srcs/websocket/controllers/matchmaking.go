@@ -0,0 +1,233 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+	"websocket/models"
+
+	"github.com/google/uuid"
+)
+
+const (
+	MatchmakingTick = time.Second
+	// Win rate gap accepted right away, then widened every step until
+	// anybody matches.
+	MatchmakingBaseWindow = 0.05
+	MatchmakingWindowStep = 0.05
+	MatchmakingStepPeriod = 5 * time.Second
+)
+
+type MatchmakingEntry struct {
+	Client   *Client
+	WinRate  float64
+	JoinedAt time.Time
+}
+
+type MatchmakingEvent struct {
+	models.Event
+	UserId     uint64    `json:"userId"`
+	OpponentId uint64    `json:"opponentId,omitempty"`
+	LobbyId    uuid.UUID `json:"lobbyId,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
+
+type PlayerStats struct {
+	Games   int64   `json:"games"`
+	Wins    int64   `json:"wins"`
+	WinRate float64 `json:"winRate"`
+}
+
+func GetPlayerStats(userId uint64) (*PlayerStats, error) {
+	resp, err := RequestBackend("GET", fmt.Sprintf("/players/%d/stats", userId), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("received non-200 response: %s", resp.Status)
+	}
+
+	var stats PlayerStats
+	if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+		return nil, err
+	}
+	return &stats, nil
+}
+
+// Window is the win rate gap the entry accepts after waiting until now.
+func (e *MatchmakingEntry) Window(now time.Time) float64 {
+	steps := math.Floor(float64(now.Sub(e.JoinedAt)) / float64(MatchmakingStepPeriod))
+	return MatchmakingBaseWindow + steps*MatchmakingWindowStep
+}
+
+func HandleMatchmaking(h *Hub, event string, data []byte) {
+	var request MatchmakingEvent
+	if err := json.Unmarshal(data, &request); err != nil {
+		fmt.Printf("Impossible to parse MatchmakingEvent type: %v\n", err)
+		return
+	}
+	client, exists := h.Clients[request.UserId]
+	if !exists {
+		return
+	}
+
+	switch event {
+	case "MATCHMAKING_JOIN":
+		JoinMatchmaking(h, client)
+	case "MATCHMAKING_LEAVE":
+		if h.Dequeue(client.Id) {
+			SendMatchmakingEvent(client, MatchmakingEvent{Event: models.Event{Type: "MATCHMAKING_LEFT"}})
+		}
+	}
+}
+
+func SendMatchmakingEvent(client *Client, event MatchmakingEvent) {
+	event.UserId = client.Id
+	jsonData, err := json.Marshal(&event)
+	if err != nil {
+		fmt.Printf("Impossible to parse MatchmakingEvent type: %v\n", err)
+		return
+	}
+	safeSend(client.Send, jsonData)
+}
+
+func SendMatchmakingError(client *Client, reason string) {
+	SendMatchmakingEvent(client, MatchmakingEvent{
+		Event: models.Event{Type: "MATCHMAKING_ERROR"},
+		Error: reason,
+	})
+}
+
+// JoinMatchmaking asks the backend whether the client may play and how well
+// it does outside of the hub goroutine, it is queued once both answered.
+func JoinMatchmaking(h *Hub, client *Client) {
+	if !CanJoinMatchmaking(h, client) {
+		return
+	}
+
+	h.Async(func() func() {
+		if err := CheckNotBanned(client.Id); err != nil {
+			return func() { SendMatchmakingError(client, err.Error()) }
+		}
+		stats, err := GetPlayerStats(client.Id)
+		if err != nil {
+			fmt.Printf("Error on fetching player stats: %v\n", err)
+			return func() { SendMatchmakingError(client, "Matchmaking is unavailable, please try again later") }
+		}
+		return func() { EnqueueMatchmaking(h, client, stats) }
+	})
+}
+
+func CanJoinMatchmaking(h *Hub, client *Client) bool {
+	for _, entry := range h.Matchmaking {
+		if entry.Client.Id == client.Id {
+			SendMatchmakingError(client, "You are already looking for a game")
+			return false
+		}
+	}
+	if h.IsInLobby(client.Id) {
+		SendMatchmakingError(client, "You are already in a game")
+		return false
+	}
+	return true
+}
+
+// EnqueueMatchmaking checks the client again, it may have left, joined twice
+// or been invited while the backend answered.
+func EnqueueMatchmaking(h *Hub, client *Client, stats *PlayerStats) {
+	if h.Clients[client.Id] != client || !CanJoinMatchmaking(h, client) {
+		return
+	}
+
+	h.Matchmaking = append(h.Matchmaking, &MatchmakingEntry{
+		Client:   client,
+		WinRate:  stats.WinRate,
+		JoinedAt: time.Now(),
+	})
+	SendMatchmakingEvent(client, MatchmakingEvent{Event: models.Event{Type: "MATCHMAKING_JOINED"}})
+}
+
+// Dequeue removes the client from the matchmaking queue and tells whether it
+// was waiting there.
+func (h *Hub) Dequeue(clientId uint64) bool {
+	for i, entry := range h.Matchmaking {
+		if entry.Client.Id == clientId {
+			h.Matchmaking = append(h.Matchmaking[:i], h.Matchmaking[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (h *Hub) IsInLobby(clientId uint64) bool {
+	for _, lobby := range h.Lobbies {
+		if (lobby.Sender != nil && lobby.Sender.Id == clientId) || (lobby.Receiver != nil && lobby.Receiver.Id == clientId) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchPlayers runs on every matchmaking tick. The longest waiting players
+// are served first, each one with the closest opponent both windows accept.
+// Pairs whose relation the hub does not know yet are skipped, it is fetched
+// in the background for a later tick.
+func MatchPlayers(h *Hub) {
+	now := time.Now()
+	for i := 0; i < len(h.Matchmaking); i++ {
+		entry := h.Matchmaking[i]
+		best := -1
+		bestGap := math.Inf(1)
+		for j := i + 1; j < len(h.Matchmaking); j++ {
+			candidate := h.Matchmaking[j]
+			gap := math.Abs(entry.WinRate - candidate.WinRate)
+			if gap > min(entry.Window(now), candidate.Window(now)) || gap >= bestGap {
+				continue
+			}
+			relation, known := h.CachedRelation(entry.Client.Id, candidate.Client.Id)
+			if !known {
+				h.WithRelation(entry.Client.Id, candidate.Client.Id, func(*Relation, error) {})
+				continue
+			}
+			if relation.Blocked {
+				continue
+			}
+			best = j
+			bestGap = gap
+		}
+		if best == -1 {
+			continue
+		}
+
+		opponent := h.Matchmaking[best]
+		h.Matchmaking = append(h.Matchmaking[:best], h.Matchmaking[best+1:]...)
+		h.Matchmaking = append(h.Matchmaking[:i], h.Matchmaking[i+1:]...)
+		i--
+		StartMatch(h, entry.Client, opponent.Client)
+	}
+}
+
+// StartMatch opens a lobby between the two players as if one had accepted
+// the other's invitation, the ready check then starts the game.
+func StartMatch(h *Hub, player1 *Client, player2 *Client) {
+	request := LobbyEvent{
+		LobbyId:  uuid.New(),
+		Sender:   LobbyUserState{Id: player1.Id},
+		Receiver: LobbyUserState{Id: player2.Id},
+	}
+
+	SendMatchmakingEvent(player1, MatchmakingEvent{
+		Event:      models.Event{Type: "MATCHMAKING_FOUND"},
+		OpponentId: player2.Id,
+		LobbyId:    request.LobbyId,
+	})
+	SendMatchmakingEvent(player2, MatchmakingEvent{
+		Event:      models.Event{Type: "MATCHMAKING_FOUND"},
+		OpponentId: player1.Id,
+		LobbyId:    request.LobbyId,
+	})
+	LobbyCreation(h, request)
+}
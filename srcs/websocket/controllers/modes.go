@@ -0,0 +1,100 @@
+package controllers
+
+import "math"
+
+const (
+	ClassicModeName = "classic"
+	SpecialModeName = "special"
+
+	// Special mode tuning, all counted per rally.
+	specialShrinkStep      = 8
+	specialMinPaddleRatio  = 0.5
+	specialSpeedRampStep   = 0.05
+	specialMaxSpeedRatio   = 1.6
+	specialMultiBallPeriod = 10 * 60 // ticks, about 10 seconds
+	specialMaxExtraBalls   = 2
+)
+
+// GameMode plugs modifiers into the simulation. A mode instance belongs to a
+// single game, so it may keep state about the running rally.
+type GameMode interface {
+	Name() string
+	BoostsEnabled() bool
+	OnPaddleHit(g *Game, ball *Ball)
+	OnTick(g *Game)
+	OnPoint(g *Game)
+}
+
+// NewGameMode returns the mode a lobby asked for.
+func NewGameMode(isGameMode bool) GameMode {
+	if isGameMode {
+		return &SpecialMode{}
+	}
+	return ClassicMode{}
+}
+
+// ClassicMode is plain pong, without boosts.
+type ClassicMode struct{}
+
+func (ClassicMode) Name() string                    { return ClassicModeName }
+func (ClassicMode) BoostsEnabled() bool             { return false }
+func (ClassicMode) OnPaddleHit(g *Game, ball *Ball) {}
+func (ClassicMode) OnTick(g *Game)                  {}
+func (ClassicMode) OnPoint(g *Game)                 {}
+
+// SpecialMode enables boosts, and as a rally goes on the paddles shrink, the
+// balls speed up and extra balls join.
+type SpecialMode struct {
+	rallyHits  int
+	rallyTicks int
+}
+
+func (m *SpecialMode) Name() string {
+	return SpecialModeName
+}
+
+func (m *SpecialMode) BoostsEnabled() bool {
+	return true
+}
+
+func (m *SpecialMode) OnPaddleHit(g *Game, ball *Ball) {
+	m.rallyHits++
+
+	minHeight := g.Rules.PaddleHeight * specialMinPaddleRatio
+	g.State.Paddles.Height = math.Max(g.State.Paddles.Height-specialShrinkStep, minHeight)
+
+	// The ramp never takes the ball past the paddle width in one tick,
+	// the next paddle would miss it otherwise.
+	ramp := math.Min(1+float64(m.rallyHits)*specialSpeedRampStep, specialMaxSpeedRatio)
+	ramp = math.Min(ramp, paddleWidth/math.Abs(ball.DX))
+	ball.DX *= ramp
+	ball.DY *= ramp
+}
+
+// OnTick draws extra balls from the game's seeded source so replays get the
+// same ones.
+func (m *SpecialMode) OnTick(g *Game) {
+	m.rallyTicks++
+	if m.rallyTicks%specialMultiBallPeriod != 0 || len(g.State.ExtraBalls) >= specialMaxExtraBalls {
+		return
+	}
+
+	direction := 1.0
+	if g.rand.Intn(2) == 0 {
+		direction = -1
+	}
+	g.State.ExtraBalls = append(g.State.ExtraBalls, Ball{
+		X:      CanvasWidth / 2,
+		Y:      CanvasHeight / 2,
+		DX:     direction * g.Rules.BallSpeed,
+		DY:     (g.rand.Float64()*2 - 1) * g.Rules.BallSpeed / 2,
+		Radius: g.State.Ball.Radius,
+	})
+}
+
+func (m *SpecialMode) OnPoint(g *Game) {
+	m.rallyHits = 0
+	m.rallyTicks = 0
+	g.State.Paddles.Height = g.Rules.PaddleHeight
+	g.State.ExtraBalls = nil
+}
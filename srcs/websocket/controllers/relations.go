@@ -0,0 +1,81 @@
+package controllers
+
+import (
+	"fmt"
+	"time"
+)
+
+// Relations are cached by the hub so that typing, matchmaking and spectating
+// do not ask the backend on every event. A change of friendship or block is
+// seen once the entry expired.
+const RelationTTL = 30 * time.Second
+
+// relationKey orders the pair, the backend answers the same relation both
+// ways.
+type relationKey struct {
+	Low  uint64
+	High uint64
+}
+
+func newRelationKey(userId uint64, targetId uint64) relationKey {
+	if userId > targetId {
+		userId, targetId = targetId, userId
+	}
+	return relationKey{Low: userId, High: targetId}
+}
+
+type cachedRelation struct {
+	Relation  *Relation
+	FetchedAt time.Time
+}
+
+// CachedRelation returns the relation of the pair if the hub knows it.
+func (h *Hub) CachedRelation(userId uint64, targetId uint64) (*Relation, bool) {
+	cached, exists := h.Relations[newRelationKey(userId, targetId)]
+	if !exists || time.Since(cached.FetchedAt) > RelationTTL {
+		return nil, false
+	}
+	return cached.Relation, true
+}
+
+// WithRelation runs then on the hub goroutine with the relation of the pair.
+// It is called right away when the relation is cached, otherwise once the
+// backend answered; callers must check again any hub state they rely on.
+func (h *Hub) WithRelation(userId uint64, targetId uint64, then func(relation *Relation, err error)) {
+	if relation, exists := h.CachedRelation(userId, targetId); exists {
+		then(relation, nil)
+		return
+	}
+
+	key := newRelationKey(userId, targetId)
+	waiting, fetching := h.RelationFetches[key]
+	h.RelationFetches[key] = append(waiting, then)
+	if fetching {
+		return
+	}
+
+	h.Async(func() func() {
+		relation, err := GetRelation(key.Low, key.High)
+		return func() {
+			if err != nil {
+				fmt.Printf("Error on fetching relation: %v\n", err)
+			} else {
+				h.Relations[key] = &cachedRelation{Relation: relation, FetchedAt: time.Now()}
+			}
+			waiting := h.RelationFetches[key]
+			delete(h.RelationFetches, key)
+			for _, then := range waiting {
+				then(relation, err)
+			}
+		}
+	})
+}
+
+// PruneRelations drops the expired entries, the hub calls it periodically.
+func (h *Hub) PruneRelations() {
+	for key, cached := range h.Relations {
+		if time.Since(cached.FetchedAt) > RelationTTL {
+			delete(h.Relations, key)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"websocket/models"
+)
+
+// GameRules is the ruleset of one game, negotiated in the lobby before both
+// players are ready. Tournament games always play the defaults.
+type GameRules struct {
+	WinningScore     uint8   `json:"winningScore"`
+	BallSpeed        float64 `json:"ballSpeed"`
+	PaddleHeight     float64 `json:"paddleHeight"`
+	PaddleSpeed      float64 `json:"paddleSpeed"`
+	CollisionToBoost int     `json:"collisionToBoost"`
+	BoostMultiplier  float64 `json:"boostMultiplier"`
+}
+
+func DefaultGameRules() GameRules {
+	return GameRules{
+		WinningScore:     WinningScore,
+		BallSpeed:        BallSpeed,
+		PaddleHeight:     paddleHeight,
+		PaddleSpeed:      paddleSpeed,
+		CollisionToBoost: collisionToBoost,
+		BoostMultiplier:  boostMultiplier,
+	}
+}
+
+// Validate keeps the rules in ranges the canvas and the physics can handle.
+// Collisions are only checked once per tick, so a boosted ball must not move
+// further than the paddle width in one, the special mode ramp is capped the
+// same way.
+func (r GameRules) Validate() error {
+	switch {
+	case r.WinningScore < 1 || r.WinningScore > 11:
+		return fmt.Errorf("The score limit must be between 1 and 11")
+	case r.BallSpeed < 4 || r.BallSpeed > 12:
+		return fmt.Errorf("The ball speed must be between 4 and 12")
+	case r.PaddleHeight < 60 || r.PaddleHeight > 240:
+		return fmt.Errorf("The paddle size must be between 60 and 240")
+	case r.PaddleSpeed < 4 || r.PaddleSpeed > 16:
+		return fmt.Errorf("The paddle speed must be between 4 and 16")
+	case r.CollisionToBoost < 1 || r.CollisionToBoost > 10:
+		return fmt.Errorf("The hits needed for a boost must be between 1 and 10")
+	case r.BoostMultiplier < 1 || r.BoostMultiplier > 2.5:
+		return fmt.Errorf("The boost multiplier must be between 1 and 2.5")
+	case r.BallSpeed*r.BoostMultiplier > paddleWidth:
+		return fmt.Errorf("The ball speed times the boost multiplier must not exceed %d", paddleWidth)
+	}
+	return nil
+}
+
+// UpdateRules applies rules proposed by one of the players, both players
+// have to be ready again so nobody plays rules they did not see.
+func UpdateRules(h *Hub, request LobbyEvent) {
+	lobby, exists := h.Lobbies[request.LobbyId]
+	if !exists {
+		fmt.Printf("Lobby not found: %s\n", request.LobbyId)
+		return
+	}
+	client, exists := h.Clients[request.UserId]
+	if !exists || !lobby.IsPlayer(client.Id) || !lobby.ArePlayersReachable() {
+		return
+	}
+
+	var reason string
+	switch {
+	case lobby.IsTournamentGame:
+		reason = "Tournament games use the default rules"
+	case lobby.Game != nil:
+		reason = "The game has already started"
+	case lobby.PlayersReady[0] && lobby.PlayersReady[1]:
+		reason = "Both players are ready"
+	case request.Rules == nil:
+		reason = "No rules were provided"
+	default:
+		if err := request.Rules.Validate(); err != nil {
+			reason = err.Error()
+		}
+	}
+	if reason != "" {
+		rejection := LobbyErrorEvent{
+			Event: models.Event{
+				Type: "LOBBY_RULES_REJECTED",
+			},
+			LobbyId: lobby.Id,
+			Error:   reason,
+		}
+		rejectionJson, _ := json.Marshal(&rejection)
+		safeSend(client.Send, rejectionJson)
+		return
+	}
+
+	lobby.Rules = *request.Rules
+	lobby.PlayersReady = [2]bool{false, false}
+
+	event := LobbyEvent{
+		Event: models.Event{
+			Type: "LOBBY_RULES_UPDATED",
+		},
+		LobbyId:  lobby.Id,
+		UserId:   client.Id,
+		Sender:   LobbyUserState{Id: lobby.Sender.Id},
+		Receiver: LobbyUserState{Id: lobby.Receiver.Id},
+		Rules:    &lobby.Rules,
+	}
+	jsonData, err := json.Marshal(&event)
+	if err != nil {
+		fmt.Printf("Impossible to parse LobbyEvent type: %v\n", err)
+		return
+	}
+
+	safeSend(lobby.Sender.Send, jsonData)
+	safeSend(lobby.Receiver.Send, jsonData)
+}
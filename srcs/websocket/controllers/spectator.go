@@ -0,0 +1,289 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"websocket/models"
+
+	"github.com/google/uuid"
+)
+
+type LiveGame struct {
+	LobbyId          uuid.UUID `json:"lobbyId"`
+	Player1Id        uint64    `json:"player1Id"`
+	Player2Id        uint64    `json:"player2Id"`
+	Score            Score     `json:"score"`
+	IsTournamentGame bool      `json:"isTournamentGame"`
+	IsGameMode       bool      `json:"isGameMode"`
+	Spectators       int       `json:"spectators"`
+	Friends          bool      `json:"friends"`
+}
+
+type LiveGamesEvent struct {
+	models.Event
+	Games []LiveGame `json:"games"`
+}
+
+type SpectateEvent struct {
+	models.Event
+	LobbyId    uuid.UUID `json:"lobbyId"`
+	Game       *LiveGame `json:"game,omitempty"`
+	Spectators int       `json:"spectators"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// Spectators are read and written from both the hub and the game routine,
+// every access goes through the lobby mutex.
+func (l *Lobby) AddSpectator(client *Client) {
+	l.Mutex.Lock()
+	defer l.Mutex.Unlock()
+	if l.Spectators == nil {
+		l.Spectators = make(map[uint64]*Client)
+	}
+	l.Spectators[client.Id] = client
+}
+
+func (l *Lobby) RemoveSpectator(clientId uint64) bool {
+	l.Mutex.Lock()
+	defer l.Mutex.Unlock()
+	if _, exists := l.Spectators[clientId]; !exists {
+		return false
+	}
+	delete(l.Spectators, clientId)
+	return true
+}
+
+func (l *Lobby) SpectatorCount() int {
+	l.Mutex.Lock()
+	defer l.Mutex.Unlock()
+	return len(l.Spectators)
+}
+
+func (l *Lobby) SendToSpectators(message []byte) {
+	l.Mutex.Lock()
+	defer l.Mutex.Unlock()
+	for _, spectator := range l.Spectators {
+		safeSend(spectator.Send, message)
+	}
+}
+
+// IsLive tells whether the lobby holds a game that can be watched.
+func (l *Lobby) IsLive() bool {
+	return l.ArePlayersReachable() && l.Game != nil && l.Game.Snapshot().Winner == 0
+}
+
+func (l *Lobby) LiveGame() LiveGame {
+	return LiveGame{
+		LobbyId:          l.Id,
+		Player1Id:        l.Sender.Id,
+		Player2Id:        l.Receiver.Id,
+		Score:            l.Game.Snapshot().Score,
+		IsTournamentGame: l.IsTournamentGame,
+		IsGameMode:       l.IsGameMode,
+		Spectators:       l.SpectatorCount(),
+	}
+}
+
+func (l *Lobby) IsPlayer(clientId uint64) bool {
+	return (l.Sender != nil && l.Sender.Id == clientId) || (l.Receiver != nil && l.Receiver.Id == clientId)
+}
+
+func HandleSpectate(h *Hub, event string, request LobbyEvent) {
+	client, exists := h.Clients[request.UserId]
+	if !exists {
+		return
+	}
+
+	switch event {
+	case "LOBBY_SPECTATE":
+		SpectateLobby(h, client, request.LobbyId)
+	case "LOBBY_SPECTATE_LEAVE":
+		if lobby, exists := h.Lobbies[request.LobbyId]; exists && lobby.RemoveSpectator(client.Id) {
+			BroadcastSpectatorCount(lobby)
+		}
+	case "LOBBY_LIST_LIVE":
+		ListLiveGames(h, client)
+	}
+}
+
+func SendSpectateEvent(client *Client, event SpectateEvent) {
+	jsonData, err := json.Marshal(&event)
+	if err != nil {
+		fmt.Printf("Impossible to parse SpectateEvent type: %v\n", err)
+		return
+	}
+	safeSend(client.Send, jsonData)
+}
+
+func SendSpectateError(client *Client, lobbyId uuid.UUID, reason string) {
+	SendSpectateEvent(client, SpectateEvent{
+		Event:   models.Event{Type: "LOBBY_SPECTATE_ERROR"},
+		LobbyId: lobbyId,
+		Error:   reason,
+	})
+}
+
+// SpectateLobby lets the client in once the hub knows neither player blocked
+// it, the relations being fetched off the hub when needed.
+func SpectateLobby(h *Hub, client *Client, lobbyId uuid.UUID) {
+	lobby := spectatableLobby(h, client, lobbyId)
+	if lobby == nil {
+		return
+	}
+	player1, player2 := lobby.Sender.Id, lobby.Receiver.Id
+
+	h.WithRelation(client.Id, player1, func(relation *Relation, err error) {
+		if err != nil || relation.Blocked {
+			SendSpectateError(client, lobbyId, "You cannot watch this game")
+			return
+		}
+		h.WithRelation(client.Id, player2, func(relation *Relation, err error) {
+			if err != nil || relation.Blocked {
+				SendSpectateError(client, lobbyId, "You cannot watch this game")
+				return
+			}
+			joinSpectators(h, client, lobbyId)
+		})
+	})
+}
+
+func spectatableLobby(h *Hub, client *Client, lobbyId uuid.UUID) *Lobby {
+	lobby, exists := h.Lobbies[lobbyId]
+	if !exists || !lobby.IsLive() {
+		SendSpectateError(client, lobbyId, "This game is not running")
+		return nil
+	}
+	if lobby.IsPlayer(client.Id) {
+		SendSpectateError(client, lobbyId, "You are playing this game")
+		return nil
+	}
+	return lobby
+}
+
+// joinSpectators checks the lobby again, the game may have ended while the
+// relations were fetched.
+func joinSpectators(h *Hub, client *Client, lobbyId uuid.UUID) {
+	if h.Clients[client.Id] != client {
+		return
+	}
+	lobby := spectatableLobby(h, client, lobbyId)
+	if lobby == nil {
+		return
+	}
+
+	// Watching one game at a time.
+	h.DetachSpectator(client.Id)
+	lobby.AddSpectator(client)
+
+	game := lobby.LiveGame()
+	SendSpectateEvent(client, SpectateEvent{
+		Event:      models.Event{Type: "LOBBY_SPECTATE_JOINED"},
+		LobbyId:    lobby.Id,
+		Game:       &game,
+		Spectators: game.Spectators,
+	})
+	BroadcastSpectatorCount(lobby)
+}
+
+// DetachSpectator removes the client from every lobby it watches.
+func (h *Hub) DetachSpectator(clientId uint64) {
+	for _, lobby := range h.Lobbies {
+		if lobby.RemoveSpectator(clientId) {
+			BroadcastSpectatorCount(lobby)
+		}
+	}
+}
+
+func BroadcastSpectatorCount(lobby *Lobby) {
+	event := SpectateEvent{
+		Event:      models.Event{Type: "LOBBY_SPECTATORS"},
+		LobbyId:    lobby.Id,
+		Spectators: lobby.SpectatorCount(),
+	}
+	jsonData, err := json.Marshal(&event)
+	if err != nil {
+		fmt.Printf("Impossible to parse SpectateEvent type: %v\n", err)
+		return
+	}
+	if lobby.Sender != nil {
+		safeSend(lobby.Sender.Send, jsonData)
+	}
+	if lobby.Receiver != nil {
+		safeSend(lobby.Receiver.Send, jsonData)
+	}
+	lobby.SendToSpectators(jsonData)
+}
+
+// ListLiveGames answers with the running games the client may watch, the
+// ones involving a friend first. The relations the hub does not know are
+// fetched first, off the hub.
+func ListLiveGames(h *Hub, client *Client) {
+	missing := map[uint64]bool{}
+	for _, lobby := range h.Lobbies {
+		if !lobby.IsLive() || lobby.IsPlayer(client.Id) {
+			continue
+		}
+		for _, playerId := range []uint64{lobby.Sender.Id, lobby.Receiver.Id} {
+			if _, known := h.CachedRelation(client.Id, playerId); !known {
+				missing[playerId] = true
+			}
+		}
+	}
+	if len(missing) == 0 {
+		SendLiveGames(h, client)
+		return
+	}
+
+	pending := len(missing)
+	for playerId := range missing {
+		h.WithRelation(client.Id, playerId, func(*Relation, error) {
+			pending--
+			if pending == 0 && h.Clients[client.Id] == client {
+				SendLiveGames(h, client)
+			}
+		})
+	}
+}
+
+// SendLiveGames lists the games from the cached relations only. A game with
+// a player whose relation could not be fetched is left out.
+func SendLiveGames(h *Hub, client *Client) {
+	games := []LiveGame{}
+	for _, lobby := range h.Lobbies {
+		if !lobby.IsLive() || lobby.IsPlayer(client.Id) {
+			continue
+		}
+		game := lobby.LiveGame()
+		visible := true
+		for _, playerId := range []uint64{game.Player1Id, game.Player2Id} {
+			relation, known := h.CachedRelation(client.Id, playerId)
+			if !known {
+				visible = false
+				break
+			}
+			visible = visible && !relation.Blocked
+			game.Friends = game.Friends || relation.Friends
+		}
+		if visible {
+			games = append(games, game)
+		}
+	}
+	sort.SliceStable(games, func(i, j int) bool {
+		if games[i].Friends != games[j].Friends {
+			return games[i].Friends
+		}
+		return games[i].Spectators > games[j].Spectators
+	})
+
+	event := LiveGamesEvent{
+		Event: models.Event{Type: "LOBBY_LIVE_LIST"},
+		Games: games,
+	}
+	jsonData, err := json.Marshal(&event)
+	if err != nil {
+		fmt.Printf("Impossible to parse LiveGamesEvent type: %v\n", err)
+		return
+	}
+	safeSend(client.Send, jsonData)
+}
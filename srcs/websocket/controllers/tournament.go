@@ -70,7 +70,7 @@ type TournamentErrorEvent struct {
 func HandleTournament(h *Hub, event string, data []byte) {
 	var request TournamentEvent
 	if err := json.Unmarshal(data, &request); err != nil {
-		fmt.Printf("Impossible to parse TournamentEvent type: ", err.Error())
+		fmt.Printf("Impossible to parse TournamentEvent type: %v\n", err)
 		return
 	}
 	switch event {
@@ -86,6 +86,10 @@ func HandleTournament(h *Hub, event string, data []byte) {
 		StartTournament(h, request)
 	case "TOURNAMENT_TREE_STATE":
 		GetTreeState(h, request)
+	case "TOURNAMENT_ADMIN_LIST":
+		ListTournaments(h, request)
+	case "TOURNAMENT_ADMIN_CANCEL":
+		CancelTournament(h, request)
 	}
 }
 
@@ -158,19 +162,47 @@ func SendTournamentError(h *Hub, client *Client, code string, errorMessage strin
 }
 
 func CreateTournament(h *Hub, request TournamentEvent) {
+	h.WhenNotBanned(request.UserId, func(err error) {
+		client, exists := h.Clients[request.UserId]
+		if !exists {
+			return
+		}
+		if err != nil {
+			SendTournamentError(h, client, request.Code, err.Error())
+			return
+		}
+		createTournament(h, request)
+	})
+}
+
+func createTournament(h *Hub, request TournamentEvent) {
 	tournament := NewTournament(h, request)
 	h.Tournaments[tournament.Id] = tournament
 	request.Player1 = tournament.Player1.Id
 	request.Code = tournament.Id
 	jsonData, err := json.Marshal(&request)
 	if err != nil {
-		fmt.Printf("Impossible to parse TournamentEvent type: ", err.Error())
+		fmt.Printf("Impossible to parse TournamentEvent type: %v\n", err)
 		return
 	}
 	safeSend(tournament.Player1.Send, jsonData)
 }
 
 func JoinTournament(h *Hub, request TournamentEvent) {
+	h.WhenNotBanned(request.UserId, func(err error) {
+		client, exists := h.Clients[request.UserId]
+		if !exists {
+			return
+		}
+		if err != nil {
+			SendTournamentError(h, client, request.Code, err.Error())
+			return
+		}
+		joinTournament(h, request)
+	})
+}
+
+func joinTournament(h *Hub, request TournamentEvent) {
 	tournament := GetTournament(h, request.Code)
 	if tournament == nil {
 		SendTournamentError(h, h.Clients[request.UserId], request.Code, fmt.Sprintf("Tournament with code <%s> does not exist", request.Code))
@@ -187,7 +219,7 @@ func JoinTournament(h *Hub, request TournamentEvent) {
 	request.Type = "TOURNAMENT_EVENT"
 	jsonData, err := json.Marshal(&request)
 	if err != nil {
-		fmt.Printf("Impossible to parse TournamentEvent type: ", err.Error())
+		fmt.Printf("Impossible to parse TournamentEvent type: %v\n", err)
 		return
 	}
 
@@ -220,7 +252,7 @@ func LeaveWaitingLobby(h *Hub, tournament *Tournament, clientLeft *Client, reque
 		request.Type = "TOURNAMENT_TERMINATE"
 		tnTerminate, err := json.Marshal(&request)
 		if err != nil {
-			fmt.Printf("Impossible to parse TournamentEvent type: ", err.Error())
+			fmt.Printf("Impossible to parse TournamentEvent type: %v\n", err)
 			return
 		}
 		SendDataToPlayers(tournament, tnTerminate)
@@ -269,6 +301,7 @@ func CreateLobbyGameTournament(player1 *Client, player2 *Client) *Lobby {
 		Timestamps:       LobbyTimestamps{},
 		PlayersReady:     [2]bool{true, true},
 		IsTournamentGame: true,
+		Rules:            DefaultGameRules(),
 	}
 }
 
@@ -341,6 +374,12 @@ func StartSemiFinals(h *Hub, tournament *Tournament) {
 	}
 
 	tournament.State = "TOURNAMENT_ON_SEMI"
+	if !tournament.Semi1.IsFinished {
+		tournament.LobbiesSemi[0].CreateGame()
+	}
+	if !tournament.Semi2.IsFinished {
+		tournament.LobbiesSemi[1].CreateGame()
+	}
 	go func() {
 		time.Sleep(300 * time.Millisecond)
 		if !tournament.Semi1.IsFinished {
@@ -356,6 +395,7 @@ func StartFinal(h *Hub, tournament *Tournament) {
 	if tournament.Final.IsFinished == false {
 		PreventPlayersGameStart(tournament, tournament.LobbyFinal)
 		tournament.State = "TOURNAMENT_ON_FINAL"
+		tournament.LobbyFinal.CreateGame()
 		go func() {
 			time.Sleep(300 * time.Millisecond)
 			StartRoutine(h, tournament.LobbyFinal)
@@ -396,6 +436,7 @@ func TournamentMonitoring(h *Hub, tournament *Tournament) {
 					StartFinal(h, tournament)
 				} else if IsFinalTournamentFinished(tournament) {
 					SendTournamentTreeState(h, tournament, event)
+					SendTournamentResultToBackend(tournament)
 					go func() {
 						time.Sleep(10 * time.Second)
 						delete(h.Tournaments, tournament.Id)
@@ -407,6 +448,22 @@ func TournamentMonitoring(h *Hub, tournament *Tournament) {
 	}()
 }
 
+// SendTournamentResultToBackend records the champion, the backend grants the
+// matching achievement.
+func SendTournamentResultToBackend(tournament *Tournament) {
+	result := map[string]interface{}{
+		"tournament_id": tournament.Id,
+		"winner_id":     tournament.LobbyFinal.Game.State.Winner,
+	}
+
+	resp, err := RequestBackend("POST", "/tournaments/result", result)
+	if err != nil {
+		fmt.Printf("Error sending tournament result: %v\n", err)
+		return
+	}
+	defer resp.Body.Close()
+}
+
 func IsFinalTournamentFinished(tournament *Tournament) bool {
 	if tournament.LobbyFinal.Game != nil && tournament.LobbyFinal.Game.State.Winner != 0 {
 		score := tournament.LobbyFinal.Game.State.Score
@@ -0,0 +1,96 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"websocket/models"
+)
+
+// Tournament administration is open to moderators and admins only.
+var TournamentAdminRoles = []string{"moderator", "admin"}
+
+type TournamentSummary struct {
+	Code    string   `json:"code"`
+	State   string   `json:"state"`
+	Players []uint64 `json:"players"`
+}
+
+type TournamentListEvent struct {
+	models.Event
+	Tournaments []TournamentSummary `json:"tournaments"`
+}
+
+func tournamentAdmin(h *Hub, request TournamentEvent) *Client {
+	client, exists := h.Clients[request.UserId]
+	if !exists {
+		return nil
+	}
+	if !client.HasRole(TournamentAdminRoles...) {
+		SendTournamentError(h, client, request.Code, "You are not allowed to administrate tournaments")
+		return nil
+	}
+	return client
+}
+
+func ListTournaments(h *Hub, request TournamentEvent) {
+	client := tournamentAdmin(h, request)
+	if client == nil {
+		return
+	}
+
+	event := TournamentListEvent{
+		Event: models.Event{
+			Type: "TOURNAMENT_ADMIN_LIST",
+		},
+		Tournaments: []TournamentSummary{},
+	}
+	for _, tournament := range h.Tournaments {
+		summary := TournamentSummary{
+			Code:    tournament.Id,
+			State:   tournament.State,
+			Players: []uint64{},
+		}
+		for _, player := range []*Client{tournament.Player1, tournament.Player2, tournament.Player3, tournament.Player4} {
+			if player != nil {
+				summary.Players = append(summary.Players, player.Id)
+			}
+		}
+		event.Tournaments = append(event.Tournaments, summary)
+	}
+
+	jsonData, err := json.Marshal(&event)
+	if err != nil {
+		fmt.Printf("Impossible to parse TournamentListEvent type: %v\n", err)
+		return
+	}
+	safeSend(client.Send, jsonData)
+}
+
+// CancelTournament closes a tournament still in its waiting room, the same way
+// its creator leaving would. Running ones finish on their own.
+func CancelTournament(h *Hub, request TournamentEvent) {
+	client := tournamentAdmin(h, request)
+	if client == nil {
+		return
+	}
+
+	tournament, exists := h.Tournaments[request.Code]
+	if !exists {
+		SendTournamentError(h, client, request.Code, fmt.Sprintf("Tournament with code <%s> does not exist", request.Code))
+		return
+	}
+	if tournament.State != "TOURNAMENT_LOBBY" {
+		SendTournamentError(h, client, request.Code, "Only tournaments in their waiting room can be cancelled")
+		return
+	}
+
+	request.Type = "TOURNAMENT_TERMINATE"
+	jsonData, err := json.Marshal(&request)
+	if err != nil {
+		fmt.Printf("Impossible to parse TournamentEvent type: %v\n", err)
+		return
+	}
+	SendDataToPlayers(tournament, jsonData)
+	delete(h.Tournaments, tournament.Id)
+	safeSend(client.Send, jsonData)
+}
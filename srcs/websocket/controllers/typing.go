@@ -0,0 +1,156 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+	"websocket/models"
+)
+
+const (
+	// A client that stops sending CHAT_TYPING_START is considered done typing.
+	TypingExpiry = 5 * time.Second
+
+	// Minimum delay between two indicators relayed for the same client.
+	TypingMinInterval = 300 * time.Millisecond
+)
+
+// TypingState is owned by the hub goroutine, timers only post events back to
+// the hub. Seq tells an expiry apart from a later CHAT_TYPING_START. The state
+// outlives an indicator so the rate limit holds, it is dropped on disconnect.
+type TypingState struct {
+	Active      bool
+	ReceiverID  uint64
+	Seq         uint64
+	Timer       *time.Timer
+	LastRelayed time.Time
+}
+
+type TypingEvent struct {
+	models.Event
+	UserId     uint64 `json:"userId"`
+	ReceiverID uint64 `json:"receiverId"`
+	Seq        uint64 `json:"seq,omitempty"`
+}
+
+func HandleTyping(h *Hub, event string, data []byte) {
+	var request TypingEvent
+	if err := json.Unmarshal(data, &request); err != nil {
+		fmt.Printf("Impossible to parse TypingEvent type: %v\n", err)
+		return
+	}
+
+	switch event {
+	case "CHAT_TYPING_START":
+		TypingStart(h, request)
+	case "CHAT_TYPING_STOP":
+		TypingStop(h, request)
+	}
+}
+
+func TypingStart(h *Hub, request TypingEvent) {
+	state, exists := h.Typing[request.UserId]
+	if !exists {
+		state = &TypingState{}
+		h.Typing[request.UserId] = state
+	}
+
+	if state.Active && state.ReceiverID == request.ReceiverID {
+		state.Seq++
+		armTypingExpiry(h, request.UserId, state)
+		return
+	}
+	if time.Since(state.LastRelayed) < TypingMinInterval {
+		return
+	}
+	StopTyping(h, request.UserId)
+
+	if _, exists := h.Clients[request.ReceiverID]; !exists || request.ReceiverID == request.UserId {
+		return
+	}
+
+	h.WithRelation(request.UserId, request.ReceiverID, func(relation *Relation, err error) {
+		if err != nil || !relation.Friends {
+			return
+		}
+		relayTypingStart(h, request)
+	})
+}
+
+// relayTypingStart shows the indicator once the relation is known, the
+// client may have left or started typing to someone else in the meantime.
+func relayTypingStart(h *Hub, request TypingEvent) {
+	state, exists := h.Typing[request.UserId]
+	if !exists || state.Active {
+		return
+	}
+	if _, exists := h.Clients[request.UserId]; !exists {
+		return
+	}
+	receiver, exists := h.Clients[request.ReceiverID]
+	if !exists {
+		return
+	}
+
+	state.Active = true
+	state.ReceiverID = request.ReceiverID
+	state.Seq++
+	state.LastRelayed = time.Now()
+	armTypingExpiry(h, request.UserId, state)
+	safeSend(receiver.Send, createTypingEvent("CHAT_TYPING_START", request.UserId, request.ReceiverID))
+}
+
+// TypingStop handles both the client event and the expiry posted by the
+// timer, the latter is ignored if the client started typing again since.
+func TypingStop(h *Hub, request TypingEvent) {
+	state, exists := h.Typing[request.UserId]
+	if !exists || (request.Seq != 0 && request.Seq != state.Seq) {
+		return
+	}
+	StopTyping(h, request.UserId)
+}
+
+// StopTyping clears the indicator of the client on the receiver side, it is
+// also used when the client disconnects.
+func StopTyping(h *Hub, userId uint64) {
+	state, exists := h.Typing[userId]
+	if !exists || !state.Active {
+		return
+	}
+	state.Timer.Stop()
+	state.Active = false
+
+	if receiver, exists := h.Clients[state.ReceiverID]; exists {
+		safeSend(receiver.Send, createTypingEvent("CHAT_TYPING_STOP", userId, state.ReceiverID))
+	}
+}
+
+func armTypingExpiry(h *Hub, userId uint64, state *TypingState) {
+	if state.Timer != nil {
+		state.Timer.Stop()
+	}
+	expiry := TypingEvent{
+		Event: models.Event{
+			Type: "CHAT_TYPING_STOP",
+		},
+		UserId:     userId,
+		ReceiverID: state.ReceiverID,
+		Seq:        state.Seq,
+	}
+	state.Timer = time.AfterFunc(TypingExpiry, func() {
+		jsonData, _ := json.Marshal(&expiry)
+		h.Broadcast <- jsonData
+	})
+}
+
+func createTypingEvent(event string, userId uint64, receiverId uint64) []byte {
+	typing := TypingEvent{
+		Event: models.Event{
+			Type: event,
+		},
+		UserId:     userId,
+		ReceiverID: receiverId,
+	}
+	jsonData, _ := json.Marshal(&typing)
+	return jsonData
+}
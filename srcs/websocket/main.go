@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"strconv"
 	"websocket/controllers"
+	"websocket/utils"
 
 	"github.com/gorilla/websocket"
 )
@@ -18,25 +19,56 @@ var upgrader = websocket.Upgrader{
 }
 
 func serveWs(hub *controllers.Hub, w http.ResponseWriter, r *http.Request) {
-	conn, err := upgrader.Upgrade(w, r, nil)
+	token, err := utils.GetRequestToken(r)
 	if err != nil {
-		w.WriteHeader(http.StatusInternalServerError)
-		log.Println(err)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
 
-	id, err := strconv.ParseUint(r.Header.Get("id"), 10, 64)
+	user, err := utils.DecryptToken(token)
+	if err != nil {
+		log.Printf("error decoding access token: %v", err)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var lastMessageId *uint64
+	if value := r.URL.Query().Get("lastMessageId"); value != "" {
+		id, err := strconv.ParseUint(value, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid lastMessageId", http.StatusBadRequest)
+			return
+		}
+		lastMessageId = &id
+	}
+
+	// A suspended user is refused before the upgrade. When the backend
+	// cannot tell, nobody is let in.
+	sanctions, err := controllers.GetSanctions(uint64(user.ID))
+	if err != nil {
+		log.Printf("error fetching sanctions: %v", err)
+		http.Error(w, "Service unavailable", http.StatusServiceUnavailable)
+		return
+	}
+	if sanctions.SuspendedUntil != nil {
+		http.Error(w, "Account suspended", http.StatusForbidden)
+		return
+	}
+
+	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
-		w.WriteHeader(http.StatusUnauthorized)
 		log.Println(err)
 		return
 	}
 
 	client := &controllers.Client{
-		Id:   id,
+		Id:   uint64(user.ID),
+		Role: user.Role,
 		Hub:  hub,
 		Conn: conn,
 		Send: make(chan []byte, 1024),
+
+		LastMessageID: lastMessageId,
 	}
 
 	client.Hub.Register <- client
@@ -50,16 +82,11 @@ func main() {
 	go hub.Run()
 
 	http.HandleFunc("/ws", func(w http.ResponseWriter, r *http.Request) {
-		values := r.URL.Query().Get("id")
-		_, err := strconv.ParseUint(values, 10, 64)
-		if values == "" || err != nil {
-			log.Printf("error decoding id query: %v", err)
-			w.WriteHeader(http.StatusUnauthorized)
-			return
-		}
-		r.Header.Add("id", values)
 		serveWs(hub, w, r)
 	})
+	http.HandleFunc("/internal/events", func(w http.ResponseWriter, r *http.Request) {
+		controllers.ServeInternalEvent(hub, w, r)
+	})
 	log.Println("Server started on :4001")
 	err := http.ListenAndServe(":4001", nil)
 	if err != nil {
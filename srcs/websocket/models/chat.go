@@ -1,14 +1,56 @@
 package models
 
+import "time"
+
 type Event struct {
 	Type string `json:"type"`
 }
 
+// MessageEvent keys match the frontend ChatMessage type.
 type MessageEvent struct {
 	Event
-	Data       string `json:"data"`
-	SenderID   uint64 `json:"senderId"`
-	ReceiverID uint64 `json:"receiverId"`
+	MessageID  uint64     `json:"messageId,omitempty"`
+	Data       string     `json:"data"`
+	SenderID   uint64     `json:"senderID"`
+	ReceiverID uint64     `json:"receiverID"`
+	CreatedAt  *time.Time `json:"createdAt,omitempty"`
+}
+
+// ReceiptEvent acknowledges messages FriendId sent to UserId: CHAT_DELIVERED
+// for a single message, CHAT_READ for every message up to MessageID.
+type ReceiptEvent struct {
+	Event
+	UserId    uint64 `json:"userId"`
+	FriendId  uint64 `json:"friendId"`
+	MessageID uint64 `json:"messageId"`
+}
+
+// SyncEvent is sent by a client as CHAT_SYNC to get every message newer than
+// LastMessageID replayed, the hub answers CHAT_SYNC_DONE once it is done.
+type SyncEvent struct {
+	Event
+	UserId        uint64 `json:"userId"`
+	LastMessageID uint64 `json:"lastMessageId"`
+	HasMore       bool   `json:"hasMore"`
+}
+
+// ChannelMessageEvent is a message posted in a group channel, relayed to
+// every member online. CHANNEL_ERROR reuses it to tell the sender why it was
+// not.
+type ChannelMessageEvent struct {
+	Event
+	MessageID uint64     `json:"messageId,omitempty"`
+	ChannelID uint64     `json:"channelId"`
+	SenderID  uint64     `json:"senderID"`
+	Data      string     `json:"data"`
+	Error     string     `json:"error,omitempty"`
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+}
+
+type ChatErrorEvent struct {
+	Event
+	ReceiverID uint64 `json:"receiverID"`
+	Error      string `json:"error"`
 }
 
 type OnlineUsersEvent struct {
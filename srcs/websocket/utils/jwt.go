@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Mirror of backend/utils/jwt.go: both services must decode the access token
+// the same way, keep the two files in sync.
+type UserToken struct {
+	ID        uint
+	Role      string
+	SessionID string
+	JTI       string
+	ExpiresAt time.Time
+}
+
+func DecryptToken(AccessToken string) (*UserToken, error) {
+	key := []byte(os.Getenv("JWT_SIGNATURE"))
+	token, err := jwt.Parse(AccessToken, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("Unexpected signature method: %v", token.Header["alg"])
+		}
+		return key, nil
+	}, jwt.WithExpirationRequired(), jwt.WithIssuedAt())
+
+	if err != nil {
+		return nil, err
+	}
+
+	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
+		id, ok := claims["ID"].(float64)
+		if !ok {
+			return nil, fmt.Errorf("ID manquant ou de type incorrect")
+		}
+
+		role, ok := claims["role"].(string)
+		if !ok || role == "" {
+			return nil, fmt.Errorf("role manquant ou de type incorrect")
+		}
+
+		sid, ok := claims["sid"].(string)
+		if !ok || sid == "" {
+			return nil, fmt.Errorf("sid manquant ou de type incorrect")
+		}
+
+		jti, ok := claims["jti"].(string)
+		if !ok || jti == "" {
+			return nil, fmt.Errorf("jti manquant ou de type incorrect")
+		}
+
+		exp, err := claims.GetExpirationTime()
+		if err != nil {
+			return nil, err
+		}
+
+		return &UserToken{
+			ID:        uint(id),
+			Role:      role,
+			SessionID: sid,
+			JTI:       jti,
+			ExpiresAt: exp.Time,
+		}, nil
+	}
+	return nil, fmt.Errorf("Invalid token")
+}
+
+// GetRequestToken returns the access token carried by the request, looking at
+// the access_token cookie first and falling back to an Authorization bearer.
+func GetRequestToken(r *http.Request) (string, error) {
+	if cookie, err := r.Cookie("access_token"); err == nil && cookie.Value != "" {
+		return cookie.Value, nil
+	}
+
+	header := r.Header.Get("Authorization")
+	if token, found := strings.CutPrefix(header, "Bearer "); found && token != "" {
+		return token, nil
+	}
+	return "", fmt.Errorf("Missing access token")
+}